@@ -0,0 +1,218 @@
+package forge
+
+import "net/http"
+
+// Vote is the decision of a Voter on a permission
+type Vote int
+
+// Votes
+const (
+	AccessDenied  Vote = -1
+	AccessAbstain Vote = 0
+	AccessGranted Vote = 1
+)
+
+// DecisionStrategy combines the Votes of all Voters into a decision
+type DecisionStrategy int
+
+// Decision Strategies
+const (
+	// StrategyAffirmative grants access as soon as one Voter grants it
+	StrategyAffirmative DecisionStrategy = iota
+	// StrategyConsensus grants access when more Voters grant than deny, ties are denied
+	StrategyConsensus
+	// StrategyUnanimous grants access when at least one Voter grants and none deny
+	StrategyUnanimous
+)
+
+// RoleProvider is a User with roles
+type RoleProvider interface {
+	User
+	GetRoles() []string
+}
+
+// Authorizer is a User that votes on its own permissions, it is asked before any Voter
+type Authorizer interface {
+	User
+	Authorize(permission string, r *http.Request) Vote
+}
+
+// Voter votes on whether a User has a permission for an http.Request
+type Voter interface {
+	Vote(user User, permission string, r *http.Request) Vote
+}
+
+// VoterFunc is a function Voter
+type VoterFunc func(user User, permission string, r *http.Request) Vote
+
+// Vote satisfies the Voter interface
+func (voter VoterFunc) Vote(user User, permission string, r *http.Request) Vote {
+	return voter(user, permission, r)
+}
+
+// RoleHierarchy maps a role to the roles it includes, like "admin" to "editor"
+type RoleHierarchy map[string][]string
+
+// Expand returns the roles with every role they include, directly or indirectly
+func (hierarchy RoleHierarchy) Expand(roles []string) []string {
+	expanded := []string{}
+	seen := map[string]bool{}
+
+	queue := append([]string{}, roles...)
+	for len(queue) > 0 {
+		role := queue[0]
+		queue = queue[1:]
+
+		if seen[role] {
+			continue
+		}
+
+		seen[role] = true
+		expanded = append(expanded, role)
+		queue = append(queue, hierarchy[role]...)
+	}
+
+	return expanded
+}
+
+// Authorization decides what the User authenticated by Security may do. Roles come
+// from RoleProvider users and include their Hierarchy. Permissions are decided by
+// Authorizer users, the Permissions granted to roles and the Voters, combined by
+// the Strategy. A request without a User gets a 401 and a denied one a 403.
+type Authorization struct {
+	Hierarchy RoleHierarchy
+	// Permissions maps a role to the permissions it grants
+	Permissions map[string][]string
+	Voters      []Voter
+	// Strategy defaults to StrategyAffirmative
+	Strategy DecisionStrategy
+	// AllowIfAllAbstain grants access when no Voter has an opinion
+	AllowIfAllAbstain bool
+	ErrorPages        *ErrorPages
+}
+
+// Roles returns the roles of a User including the roles they include
+func (authorization *Authorization) Roles(user User) []string {
+	roleProvider, ok := user.(RoleProvider)
+	if !ok {
+		return []string{}
+	}
+
+	return authorization.Hierarchy.Expand(roleProvider.GetRoles())
+}
+
+// HasRole checks if the current User of an http.Request has a role
+func (authorization *Authorization) HasRole(r *http.Request, role string) bool {
+	user := CurrentUser(r)
+	if user == nil {
+		return false
+	}
+
+	for _, userRole := range authorization.Roles(user) {
+		if userRole == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsGranted checks if the current User of an http.Request has a permission
+func (authorization *Authorization) IsGranted(r *http.Request, permission string) bool {
+	user := CurrentUser(r)
+	if user == nil {
+		return false
+	}
+
+	granted, denied := 0, 0
+	for _, vote := range authorization.votes(user, permission, r) {
+		switch vote {
+		case AccessGranted:
+			if authorization.Strategy == StrategyAffirmative {
+				return true
+			}
+
+			granted++
+		case AccessDenied:
+			if authorization.Strategy == StrategyUnanimous {
+				return false
+			}
+
+			denied++
+		}
+	}
+
+	if granted == 0 && denied == 0 {
+		return authorization.AllowIfAllAbstain
+	}
+
+	switch authorization.Strategy {
+	case StrategyConsensus:
+		return granted > denied
+	case StrategyUnanimous:
+		return granted > 0
+	default:
+		return false
+	}
+}
+
+// RequireRole protects an http.Handler so only Users with a role can access it
+func (authorization *Authorization) RequireRole(role string, handler http.Handler) http.Handler {
+	return authorization.require(handler, func(r *http.Request) bool {
+		return authorization.HasRole(r, role)
+	})
+}
+
+// RequirePermission protects an http.Handler so only Users granted a permission can access it
+func (authorization *Authorization) RequirePermission(permission string, handler http.Handler) http.Handler {
+	return authorization.require(handler, func(r *http.Request) bool {
+		return authorization.IsGranted(r, permission)
+	})
+}
+
+func (authorization *Authorization) require(handler http.Handler, allowed func(r *http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if CurrentUser(r) == nil {
+			authorization.ErrorPages.Serve(w, r, http.StatusUnauthorized)
+			return
+		}
+
+		if !allowed(r) {
+			authorization.ErrorPages.Serve(w, r, http.StatusForbidden)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// votes collects the Votes of the Authorizer user, the role Permissions and the Voters
+func (authorization *Authorization) votes(user User, permission string, r *http.Request) []Vote {
+	votes := []Vote{}
+
+	if authorizer, ok := user.(Authorizer); ok {
+		votes = append(votes, authorizer.Authorize(permission, r))
+	}
+
+	if authorization.Permissions != nil {
+		votes = append(votes, authorization.rolePermissionVote(user, permission))
+	}
+
+	for _, voter := range authorization.Voters {
+		votes = append(votes, voter.Vote(user, permission, r))
+	}
+
+	return votes
+}
+
+func (authorization *Authorization) rolePermissionVote(user User, permission string) Vote {
+	for _, role := range authorization.Roles(user) {
+		for _, rolePermission := range authorization.Permissions[role] {
+			if rolePermission == permission {
+				return AccessGranted
+			}
+		}
+	}
+
+	return AccessAbstain
+}
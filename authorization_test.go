@@ -0,0 +1,177 @@
+package forge_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fuzzingbits/forge"
+)
+
+type roleTestUser struct {
+	Username string
+	Roles    []string
+	Denied   []string
+}
+
+func (user *roleTestUser) GetUsername() string {
+	return user.Username
+}
+
+func (user *roleTestUser) GetRoles() []string {
+	return user.Roles
+}
+
+func (user *roleTestUser) Authorize(permission string, r *http.Request) forge.Vote {
+	for _, denied := range user.Denied {
+		if denied == permission {
+			return forge.AccessDenied
+		}
+	}
+
+	return forge.AccessAbstain
+}
+
+type authorizationTestUserKey struct{}
+
+// authorizationTestSecurity authenticates the User stored under authorizationTestUserKey
+func authorizationTestSecurity(handler http.Handler) http.Handler {
+	return &forge.Security{
+		Guards:  []forge.Guard{&contextTestGuard{}},
+		Handler: handler,
+	}
+}
+
+type contextTestGuard struct {
+	securityTestGuard
+}
+
+func (guard *contextTestGuard) Supports(request *http.Request) bool {
+	return request.Context().Value(authorizationTestUserKey{}) != nil
+}
+
+func (guard *contextTestGuard) GetCredentials(request *http.Request) interface{} {
+	return request.Context().Value(authorizationTestUserKey{})
+}
+
+func (guard *contextTestGuard) GetUser(credentials interface{}) (forge.User, error) {
+	return credentials.(forge.User), nil
+}
+
+func (guard *contextTestGuard) CheckCredentials(user interface{}, credentials interface{}) (bool, error) {
+	return true, nil
+}
+
+func Test_RoleHierarchy(t *testing.T) {
+	hierarchy := forge.RoleHierarchy{
+		"admin":  {"editor", "admin"},
+		"editor": {"viewer"},
+	}
+
+	roles := strings.Join(hierarchy.Expand([]string{"admin"}), ",")
+	if roles != "admin,editor,viewer" {
+		t.Fatalf("roles: %s", roles)
+	}
+}
+
+func Test_Authorization_Router(t *testing.T) {
+	authorization := &forge.Authorization{
+		Hierarchy:   forge.RoleHierarchy{"admin": {"editor"}},
+		Permissions: map[string][]string{"editor": {"projects.delete"}},
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	router := &forge.Router{}
+	router.Handle("/admin", authorization.RequireRole("admin", ok))
+	router.Handle("/projects/7", authorization.RequirePermission("projects.delete", ok))
+
+	handler := authorizationTestSecurity(router)
+
+	admin := &roleTestUser{Username: "aaron", Roles: []string{"admin"}}
+	editor := &roleTestUser{Username: "erin", Roles: []string{"editor"}}
+	suspended := &roleTestUser{Username: "sam", Roles: []string{"admin"}, Denied: []string{"projects.delete"}}
+
+	testCases := []struct {
+		Name       string
+		User       forge.User
+		Path       string
+		StatusCode int
+	}{
+		{Name: "admin role", User: admin, Path: "/admin", StatusCode: http.StatusOK},
+		{Name: "editor role", User: editor, Path: "/admin", StatusCode: http.StatusForbidden},
+		{Name: "inherited permission", User: admin, Path: "/projects/7", StatusCode: http.StatusOK},
+		{Name: "direct permission", User: editor, Path: "/projects/7", StatusCode: http.StatusOK},
+		{Name: "affirmative outvotes the authorizer", User: suspended, Path: "/projects/7", StatusCode: http.StatusOK},
+		{Name: "no roles", User: &securityTestUser{Username: "nobody"}, Path: "/projects/7", StatusCode: http.StatusForbidden},
+		{Name: "anonymous", Path: "/admin", StatusCode: http.StatusUnauthorized},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodDelete, testCase.Path, nil)
+			if testCase.User == nil {
+				router.ServeHTTP(recorder, request)
+			} else {
+				handler.ServeHTTP(recorder, request.WithContext(context.WithValue(request.Context(), authorizationTestUserKey{}, testCase.User)))
+			}
+
+			if recorder.Code != testCase.StatusCode {
+				t.Fatalf("status: %d expected: %d", recorder.Code, testCase.StatusCode)
+			}
+		})
+	}
+}
+
+func Test_Authorization_Strategies(t *testing.T) {
+	vote := func(vote forge.Vote) forge.Voter {
+		return forge.VoterFunc(func(user forge.User, permission string, r *http.Request) forge.Vote {
+			return vote
+		})
+	}
+
+	testCases := []struct {
+		Name              string
+		Strategy          forge.DecisionStrategy
+		Voters            []forge.Voter
+		AllowIfAllAbstain bool
+		Granted           bool
+	}{
+		{Name: "affirmative grant", Strategy: forge.StrategyAffirmative, Voters: []forge.Voter{vote(forge.AccessDenied), vote(forge.AccessGranted)}, Granted: true},
+		{Name: "affirmative deny", Strategy: forge.StrategyAffirmative, Voters: []forge.Voter{vote(forge.AccessDenied), vote(forge.AccessAbstain)}, Granted: false},
+		{Name: "consensus grant", Strategy: forge.StrategyConsensus, Voters: []forge.Voter{vote(forge.AccessGranted), vote(forge.AccessGranted), vote(forge.AccessDenied)}, Granted: true},
+		{Name: "consensus tie", Strategy: forge.StrategyConsensus, Voters: []forge.Voter{vote(forge.AccessGranted), vote(forge.AccessDenied)}, Granted: false},
+		{Name: "unanimous grant", Strategy: forge.StrategyUnanimous, Voters: []forge.Voter{vote(forge.AccessGranted), vote(forge.AccessAbstain)}, Granted: true},
+		{Name: "unanimous deny", Strategy: forge.StrategyUnanimous, Voters: []forge.Voter{vote(forge.AccessGranted), vote(forge.AccessDenied)}, Granted: false},
+		{Name: "all abstain", Strategy: forge.StrategyAffirmative, Voters: []forge.Voter{vote(forge.AccessAbstain)}, Granted: false},
+		{Name: "allow if all abstain", Strategy: forge.StrategyUnanimous, Voters: []forge.Voter{vote(forge.AccessAbstain)}, AllowIfAllAbstain: true, Granted: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			authorization := &forge.Authorization{
+				Strategy:          testCase.Strategy,
+				Voters:            testCase.Voters,
+				AllowIfAllAbstain: testCase.AllowIfAllAbstain,
+			}
+
+			var granted bool
+			handler := authorizationTestSecurity(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				granted = authorization.IsGranted(r, "projects.delete")
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request = request.WithContext(context.WithValue(request.Context(), authorizationTestUserKey{}, &securityTestUser{Username: "aaron"}))
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+
+			if granted != testCase.Granted {
+				t.Fatalf("granted: %t expected: %t", granted, testCase.Granted)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package forge
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultBasicAuthCacheTTL is how long a BasicAuthGuard remembers verified credentials
+const DefaultBasicAuthCacheTTL = time.Minute
+
+// maxBasicAuthCacheEntries bounds the memory used by the cache of a BasicAuthGuard
+const maxBasicAuthCacheEntries = 1024
+
+// BasicAuthGuard is a Guard for HTTP Basic authentication. It supports every
+// http.Request so missing credentials are challenged, it should be the last Guard.
+//
+// Clients send their password with every request, so verified credentials are
+// remembered for CacheTTL instead of hashing them again each time. Wrong or unknown
+// credentials always cost a full verification, so use a Hasher far cheaper than the
+// one for form login, like a PBKDF2Hasher with a few thousand Iterations, and rate
+// limit failed attempts.
+type BasicAuthGuard struct {
+	// Realm is sent in the WWW-Authenticate challenge, defaults to "Restricted"
+	Realm string
+	// Users loads the PasswordUser for a username
+	Users UserProvider
+	// Hasher verifies passwords, defaults to a PBKDF2Hasher
+	Hasher PasswordHasher
+	// CacheTTL is how long verified credentials are remembered, defaults to
+	// DefaultBasicAuthCacheTTL, a negative CacheTTL disables the cache
+	CacheTTL      time.Duration
+	authenticator passwordAuthenticator
+	cache         map[[sha256.Size]byte]time.Time
+	cacheKey      []byte
+	cacheKeyOnce  sync.Once
+	cacheMutex    sync.Mutex
+}
+
+// Supports satisfies the Guard interface
+func (guard *BasicAuthGuard) Supports(request *http.Request) bool {
+	return true
+}
+
+// GetCredentials satisfies the Guard interface
+func (guard *BasicAuthGuard) GetCredentials(request *http.Request) interface{} {
+	username, password, ok := request.BasicAuth()
+	if !ok {
+		return nil
+	}
+
+	return PasswordCredentials{
+		Username: username,
+		Password: password,
+	}
+}
+
+// GetUser satisfies the Guard interface
+func (guard *BasicAuthGuard) GetUser(credentials interface{}) (User, error) {
+	return guard.authenticator.getUser(guard.Users, guard.Hasher, credentials)
+}
+
+// CheckCredentials satisfies the Guard interface
+func (guard *BasicAuthGuard) CheckCredentials(user interface{}, credentials interface{}) (bool, error) {
+	if guard.CacheTTL < 0 {
+		return guard.authenticator.checkCredentials(guard.Hasher, user, credentials)
+	}
+
+	passwordUser, userOK := user.(PasswordUser)
+	passwordCredentials, credentialsOK := credentials.(PasswordCredentials)
+	if !userOK || !credentialsOK {
+		return false, nil
+	}
+
+	// The password hash is part of the entry so changing a password invalidates it
+	entry := guard.cacheEntry(passwordUser.GetPasswordHash(), passwordCredentials)
+	now := time.Now()
+
+	guard.cacheMutex.Lock()
+	expiresAt, cached := guard.cache[entry]
+	guard.cacheMutex.Unlock()
+
+	if cached && now.Before(expiresAt) {
+		return true, nil
+	}
+
+	valid, err := guard.authenticator.checkCredentials(guard.Hasher, user, credentials)
+	if err == nil && valid {
+		guard.remember(entry, now)
+	}
+
+	return valid, err
+}
+
+// cacheEntry is an HMAC of the credentials with a random key, so the cache never
+// holds passwords or anything that could be checked against them offline
+func (guard *BasicAuthGuard) cacheEntry(passwordHash string, credentials PasswordCredentials) [sha256.Size]byte {
+	guard.cacheKeyOnce.Do(func() {
+		guard.cacheKey = make([]byte, 32)
+		_, _ = rand.Read(guard.cacheKey)
+	})
+
+	mac := hmac.New(sha256.New, guard.cacheKey)
+	for _, value := range []string{passwordHash, credentials.Username, credentials.Password} {
+		_ = binary.Write(mac, binary.BigEndian, uint32(len(value)))
+		mac.Write([]byte(value))
+	}
+
+	entry := [sha256.Size]byte{}
+	copy(entry[:], mac.Sum(nil))
+
+	return entry
+}
+
+func (guard *BasicAuthGuard) remember(entry [sha256.Size]byte, now time.Time) {
+	ttl := guard.CacheTTL
+	if ttl == 0 {
+		ttl = DefaultBasicAuthCacheTTL
+	}
+
+	guard.cacheMutex.Lock()
+	defer guard.cacheMutex.Unlock()
+
+	if guard.cache == nil {
+		guard.cache = make(map[[sha256.Size]byte]time.Time)
+	}
+
+	if len(guard.cache) >= maxBasicAuthCacheEntries {
+		for cachedEntry, expiresAt := range guard.cache {
+			if !now.Before(expiresAt) {
+				delete(guard.cache, cachedEntry)
+			}
+		}
+
+		if len(guard.cache) >= maxBasicAuthCacheEntries {
+			return
+		}
+	}
+
+	guard.cache[entry] = now.Add(ttl)
+}
+
+// OnAuthenticationFailure satisfies the Guard interface
+func (guard *BasicAuthGuard) OnAuthenticationFailure(w http.ResponseWriter, r *http.Request) {
+	realm := guard.Realm
+	if realm == "" {
+		realm = "Restricted"
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="`+quoteChallenge(realm)+`", charset="UTF-8"`)
+	RespondText(w, http.StatusUnauthorized, []byte(http.StatusText(http.StatusUnauthorized)))
+}
+
+// OnAuthenticationSuccess satisfies the Guard interface
+func (guard *BasicAuthGuard) OnAuthenticationSuccess(w http.ResponseWriter, r *http.Request) {}
@@ -0,0 +1,151 @@
+package forge_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fuzzingbits/forge"
+)
+
+type passwordTestUser struct {
+	Username     string
+	PasswordHash string
+}
+
+func (user *passwordTestUser) GetUsername() string {
+	return user.Username
+}
+
+func (user *passwordTestUser) GetPasswordHash() string {
+	return user.PasswordHash
+}
+
+type userTestProvider map[string]forge.User
+
+func (provider userTestProvider) LoadUser(username string) (forge.User, error) {
+	user, ok := provider[username]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+
+	return user, nil
+}
+
+var testPasswordHasher = &forge.PBKDF2Hasher{Iterations: 1000}
+
+func newUserTestProvider() userTestProvider {
+	hash, _ := testPasswordHasher.Hash("secret")
+
+	return userTestProvider{
+		"aaron": &passwordTestUser{Username: "aaron", PasswordHash: hash},
+	}
+}
+
+func Test_BasicAuthGuard(t *testing.T) {
+	security := &forge.Security{
+		Guards: []forge.Guard{
+			&forge.BasicAuthGuard{
+				Realm:  "Admin",
+				Users:  newUserTestProvider(),
+				Hasher: testPasswordHasher,
+			},
+		},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("Hello " + forge.CurrentUser(r).GetUsername()))
+		}),
+	}
+
+	testCases := []struct {
+		Name       string
+		Username   string
+		Password   string
+		StatusCode int
+		Body       string
+	}{
+		{Name: "valid", Username: "aaron", Password: "secret", StatusCode: http.StatusOK, Body: "Hello aaron"},
+		{Name: "wrong password", Username: "aaron", Password: "wrong", StatusCode: http.StatusUnauthorized, Body: "Unauthorized"},
+		{Name: "unknown user", Username: "nobody", Password: "secret", StatusCode: http.StatusUnauthorized, Body: "Unauthorized"},
+		{Name: "missing", StatusCode: http.StatusUnauthorized, Body: "Unauthorized"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if testCase.Username != "" {
+				request.SetBasicAuth(testCase.Username, testCase.Password)
+			}
+
+			handlerTest(t, handlerTestCase{
+				Handler:          security,
+				Request:          request,
+				TargetStatusCode: testCase.StatusCode,
+				CustomResponseChecker: func(t *testing.T, response *http.Response) {
+					if testCase.StatusCode == http.StatusUnauthorized && response.Header.Get("WWW-Authenticate") != `Basic realm="Admin", charset="UTF-8"` {
+						t.Fatalf("www-authenticate: %s", response.Header.Get("WWW-Authenticate"))
+					}
+
+					statusAndBodyChecker(testCase.StatusCode, testCase.Body)(t, response)
+				},
+			})
+		})
+	}
+}
+
+// countingPasswordHasher counts the verifications of testPasswordHasher
+type countingPasswordHasher struct {
+	verifications int
+}
+
+func (hasher *countingPasswordHasher) Hash(password string) (string, error) {
+	return testPasswordHasher.Hash(password)
+}
+
+func (hasher *countingPasswordHasher) Verify(hash string, password string) (bool, error) {
+	hasher.verifications++
+
+	return testPasswordHasher.Verify(hash, password)
+}
+
+func Test_BasicAuthGuard_Cache(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		CacheTTL      time.Duration
+		Passwords     []string
+		Verifications int
+	}{
+		{Name: "cached", Passwords: []string{"secret", "secret", "secret"}, Verifications: 1},
+		{Name: "wrong passwords", Passwords: []string{"secret", "wrong", "wrong"}, Verifications: 3},
+		{Name: "disabled", CacheTTL: -1, Passwords: []string{"secret", "secret"}, Verifications: 2},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			hasher := &countingPasswordHasher{}
+			security := &forge.Security{
+				Guards: []forge.Guard{
+					&forge.BasicAuthGuard{Users: newUserTestProvider(), Hasher: hasher, CacheTTL: testCase.CacheTTL},
+				},
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			}
+
+			for _, password := range testCase.Passwords {
+				request := httptest.NewRequest(http.MethodGet, "/", nil)
+				request.SetBasicAuth("aaron", password)
+
+				recorder := httptest.NewRecorder()
+				security.ServeHTTP(recorder, request)
+
+				if (password == "secret") != (recorder.Code == http.StatusOK) {
+					t.Fatalf("password %q status: %d", password, recorder.Code)
+				}
+			}
+
+			if hasher.verifications != testCase.Verifications {
+				t.Fatalf("verifications: %d expected: %d", hasher.verifications, testCase.Verifications)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package forge
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// DefaultCompressMinSize is the smallest body compressed when no MinSize is configured
+const DefaultCompressMinSize = 1024
+
+// Content Encoding Constants
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingBrotli  = "br"
+)
+
+var incompressibleContentTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/octet-stream",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-bzip2",
+	"application/x-xz",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/pdf",
+}
+
+// Compressor compresses responses with gzip or deflate for clients that accept it.
+// Brotli is not supported since there is no encoder in the standard library.
+type Compressor struct {
+	Handler http.Handler
+	// MinSize is the smallest body that will be compressed, defaults to DefaultCompressMinSize
+	MinSize int
+	// Level is the compression level from flate.HuffmanOnly to flate.BestCompression,
+	// zero or any level out of that range uses the default level
+	Level int
+}
+
+// ServerHTTP satisfies the http.Handler interface
+func (compressor *Compressor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if compressor.Handler == nil {
+		return
+	}
+
+	w.Header().Add(HeaderVary, HeaderAcceptEncoding)
+
+	encoding := negotiateEncoding(r, EncodingGzip, EncodingDeflate)
+	if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+		compressor.Handler.ServeHTTP(w, r)
+		return
+	}
+
+	minSize := compressor.MinSize
+	if minSize <= 0 {
+		minSize = DefaultCompressMinSize
+	}
+
+	level := compressor.Level
+	if level == 0 || level < flate.HuffmanOnly || level > flate.BestCompression {
+		level = flate.DefaultCompression
+	}
+
+	writer := &compressWriter{
+		ResponseWriter: w,
+		encoding:       encoding,
+		level:          level,
+		minSize:        minSize,
+		status:         http.StatusOK,
+	}
+	defer writer.close()
+
+	// Only advertise http.Flusher when the wrapped http.ResponseWriter supports it
+	if _, ok := w.(http.Flusher); ok {
+		compressor.Handler.ServeHTTP(&flushingCompressWriter{compressWriter: writer}, r)
+		return
+	}
+
+	compressor.Handler.ServeHTTP(writer, r)
+}
+
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	level       int
+	minSize     int
+	status      int
+	wroteHeader bool
+	decided     bool
+	hijacked    bool
+	buffer      bytes.Buffer
+	encoder     io.WriteCloser
+}
+
+func (writer *compressWriter) WriteHeader(status int) {
+	if writer.wroteHeader {
+		return
+	}
+
+	// Informational responses precede the final response and do not decide anything
+	if status < http.StatusOK {
+		writer.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	writer.wroteHeader = true
+	writer.status = status
+
+	switch {
+	case status == http.StatusNoContent,
+		status == http.StatusNotModified,
+		status == http.StatusPartialContent:
+		writer.passthrough()
+	}
+}
+
+func (writer *compressWriter) Write(p []byte) (int, error) {
+	if !writer.wroteHeader {
+		writer.WriteHeader(http.StatusOK)
+	}
+
+	if writer.decided {
+		if writer.encoder != nil {
+			return writer.encoder.Write(p)
+		}
+
+		return writer.ResponseWriter.Write(p)
+	}
+
+	writer.buffer.Write(p)
+	if writer.buffer.Len() >= writer.minSize {
+		if err := writer.decide(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+type flushingCompressWriter struct {
+	*compressWriter
+}
+
+func (writer *flushingCompressWriter) Flush() {
+	if !writer.decided {
+		if !writer.wroteHeader {
+			writer.WriteHeader(http.StatusOK)
+		}
+
+		_ = writer.decide()
+	}
+
+	if flusher, ok := writer.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+
+	writer.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (writer *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := writer.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	conn, readWriter, err := hijacker.Hijack()
+	if err == nil {
+		writer.hijacked = true
+	}
+
+	return conn, readWriter, err
+}
+
+func (writer *compressWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := writer.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+
+	return http.ErrNotSupported
+}
+
+// decide starts compressing if the response is eligible and writes anything buffered so far
+func (writer *compressWriter) decide() error {
+	header := writer.Header()
+
+	if header.Get(HeaderContentType) == "" && writer.buffer.Len() > 0 {
+		header.Set(HeaderContentType, http.DetectContentType(writer.buffer.Bytes()))
+	}
+
+	if header.Get(HeaderContentEncoding) != "" ||
+		header.Get("Content-Range") != "" ||
+		!isCompressibleContentType(header.Get(HeaderContentType)) {
+		return writer.passthrough()
+	}
+
+	// The HTTP deflate coding is the zlib format, not raw deflate
+	var encoder io.WriteCloser
+	var err error
+	switch writer.encoding {
+	case EncodingDeflate:
+		encoder, err = zlib.NewWriterLevel(writer.ResponseWriter, writer.level)
+	default:
+		encoder, err = gzip.NewWriterLevel(writer.ResponseWriter, writer.level)
+	}
+	if err != nil {
+		return writer.passthrough()
+	}
+
+	writer.decided = true
+	writer.encoder = encoder
+
+	header.Set(HeaderContentEncoding, writer.encoding)
+	header.Del(HeaderContentLength)
+	if etag := header.Get(HeaderETag); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set(HeaderETag, "W/"+etag)
+	}
+
+	writer.ResponseWriter.WriteHeader(writer.status)
+
+	return writer.writeBuffer(writer.encoder)
+}
+
+// passthrough sends the response uncompressed
+func (writer *compressWriter) passthrough() error {
+	if writer.decided {
+		return nil
+	}
+
+	writer.decided = true
+	writer.ResponseWriter.WriteHeader(writer.status)
+
+	return writer.writeBuffer(writer.ResponseWriter)
+}
+
+func (writer *compressWriter) writeBuffer(destination io.Writer) error {
+	if writer.buffer.Len() == 0 {
+		return nil
+	}
+
+	_, err := destination.Write(writer.buffer.Bytes())
+	writer.buffer.Reset()
+
+	return err
+}
+
+func (writer *compressWriter) close() {
+	if !writer.wroteHeader || writer.hijacked {
+		return
+	}
+
+	if !writer.decided {
+		// The whole body fit under minSize
+		_ = writer.passthrough()
+		return
+	}
+
+	if writer.encoder != nil {
+		_ = writer.encoder.Close()
+	}
+}
+
+func isCompressibleContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	if mediaType == "image/svg+xml" {
+		return true
+	}
+
+	for _, incompressible := range incompressibleContentTypes {
+		if strings.HasPrefix(mediaType, incompressible) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,266 @@
+package forge_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func compressTestHandler(contentType string, body string, statusCode int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set(forge.HeaderContentType, contentType)
+		}
+
+		w.WriteHeader(statusCode)
+		w.Write([]byte(body))
+	})
+}
+
+func Test_Compressor_Gzip(t *testing.T) {
+	body := strings.Repeat("compress me ", 200)
+	logBuffer := &bytes.Buffer{}
+
+	handler := &forge.Logger{
+		Log: log.New(logBuffer, "", 0),
+		Handler: &forge.Compressor{
+			Handler: compressTestHandler("text/plain; charset=utf-8", body, http.StatusCreated),
+		},
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(forge.HeaderAcceptEncoding, "deflate;q=0.5, gzip")
+
+	handlerTest(t, handlerTestCase{
+		Handler:          handler,
+		Request:          request,
+		TargetStatusCode: http.StatusCreated,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			if response.StatusCode != http.StatusCreated {
+				t.Fatalf("status code: %d", response.StatusCode)
+			}
+
+			if response.Header.Get(forge.HeaderContentEncoding) != forge.EncodingGzip {
+				t.Fatalf("content encoding: %s", response.Header.Get(forge.HeaderContentEncoding))
+			}
+
+			if response.Header.Get(forge.HeaderVary) != forge.HeaderAcceptEncoding {
+				t.Fatalf("vary: %s", response.Header.Get(forge.HeaderVary))
+			}
+
+			reader, err := gzip.NewReader(response.Body)
+			if err != nil {
+				t.Fatalf("gzip reader: %s", err)
+			}
+
+			responseBytes, _ := ioutil.ReadAll(reader)
+			if string(responseBytes) != body {
+				t.Fatalf("unexpected body: %s", responseBytes)
+			}
+
+			if !strings.HasPrefix(logBuffer.String(), "201 ") {
+				t.Fatalf("logged: %s", logBuffer.String())
+			}
+		},
+	})
+}
+
+func Test_Compressor_Deflate(t *testing.T) {
+	body := strings.Repeat("compress me ", 200)
+
+	handler := &forge.Compressor{
+		Handler: compressTestHandler("", body, http.StatusOK),
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(forge.HeaderAcceptEncoding, "deflate")
+
+	handlerTest(t, handlerTestCase{
+		Handler:          handler,
+		Request:          request,
+		TargetStatusCode: http.StatusOK,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			if response.Header.Get(forge.HeaderContentEncoding) != forge.EncodingDeflate {
+				t.Fatalf("content encoding: %s", response.Header.Get(forge.HeaderContentEncoding))
+			}
+
+			if !strings.HasPrefix(response.Header.Get(forge.HeaderContentType), "text/plain") {
+				t.Fatalf("content type: %s", response.Header.Get(forge.HeaderContentType))
+			}
+
+			reader, err := zlib.NewReader(response.Body)
+			if err != nil {
+				t.Fatalf("zlib reader: %s", err)
+			}
+
+			responseBytes, _ := ioutil.ReadAll(reader)
+			if string(responseBytes) != body {
+				t.Fatalf("unexpected body: %s", responseBytes)
+			}
+		},
+	})
+}
+
+func Test_Compressor_InvalidLevel(t *testing.T) {
+	body := strings.Repeat("x", 2048)
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(forge.HeaderAcceptEncoding, forge.EncodingGzip)
+
+	handlerTest(t, handlerTestCase{
+		Handler: &forge.Compressor{
+			Handler: compressTestHandler("text/plain", body, http.StatusOK),
+			Level:   42,
+		},
+		Request:          request,
+		TargetStatusCode: http.StatusOK,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			reader, err := gzip.NewReader(response.Body)
+			if err != nil {
+				t.Fatalf("gzip reader: %s", err)
+			}
+
+			responseBytes, _ := ioutil.ReadAll(reader)
+			if string(responseBytes) != body {
+				t.Fatalf("unexpected body: %s", responseBytes)
+			}
+		},
+	})
+}
+
+func Test_Compressor_Skipped(t *testing.T) {
+	largeBody := strings.Repeat("x", 2048)
+
+	testCases := []struct {
+		Name           string
+		Handler        http.Handler
+		AcceptEncoding string
+		Body           string
+	}{
+		{Name: "small body", Handler: compressTestHandler("text/plain", "tiny", http.StatusOK), AcceptEncoding: "gzip", Body: "tiny"},
+		{Name: "compressed type", Handler: compressTestHandler("image/png", largeBody, http.StatusOK), AcceptEncoding: "gzip", Body: largeBody},
+		{Name: "not accepted", Handler: compressTestHandler("text/plain", largeBody, http.StatusOK), AcceptEncoding: "identity", Body: largeBody},
+		{Name: "gzip refused", Handler: compressTestHandler("text/plain", largeBody, http.StatusOK), AcceptEncoding: "gzip;q=0, br", Body: largeBody},
+		{Name: "already encoded", Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(forge.HeaderContentEncoding, forge.EncodingBrotli)
+			w.Write([]byte(largeBody))
+		}), AcceptEncoding: "gzip, br", Body: largeBody},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set(forge.HeaderAcceptEncoding, testCase.AcceptEncoding)
+
+			handlerTest(t, handlerTestCase{
+				Handler:          &forge.Compressor{Handler: testCase.Handler},
+				Request:          request,
+				TargetStatusCode: http.StatusOK,
+				CustomResponseChecker: func(t *testing.T, response *http.Response) {
+					if response.Header.Get(forge.HeaderContentEncoding) == forge.EncodingGzip {
+						t.Fatal("response should not be gzipped")
+					}
+
+					statusAndBodyChecker(http.StatusOK, testCase.Body)(t, response)
+				},
+			})
+		})
+	}
+}
+
+// compressTestWriter records every status written and fakes hijacking
+type compressTestWriter struct {
+	*httptest.ResponseRecorder
+	statuses []int
+	hijacked bool
+}
+
+func (writer *compressTestWriter) WriteHeader(status int) {
+	writer.statuses = append(writer.statuses, status)
+	if status >= http.StatusOK {
+		writer.ResponseRecorder.WriteHeader(status)
+	}
+}
+
+func (writer *compressTestWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	writer.hijacked = true
+
+	return nil, nil, nil
+}
+
+func Test_Compressor_Informational(t *testing.T) {
+	body := strings.Repeat("compress me ", 200)
+	compressor := &forge.Compressor{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Link", "</style.css>; rel=preload")
+			w.WriteHeader(http.StatusEarlyHints)
+			w.Header().Set(forge.HeaderContentType, "text/plain")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(body))
+		}),
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(forge.HeaderAcceptEncoding, forge.EncodingGzip)
+
+	writer := &compressTestWriter{ResponseRecorder: httptest.NewRecorder()}
+	compressor.ServeHTTP(writer, request)
+
+	if !reflect.DeepEqual(writer.statuses, []int{http.StatusEarlyHints, http.StatusCreated}) {
+		t.Fatalf("statuses: %v", writer.statuses)
+	}
+
+	if writer.Header().Get(forge.HeaderContentEncoding) != forge.EncodingGzip {
+		t.Fatalf("content encoding: %s", writer.Header().Get(forge.HeaderContentEncoding))
+	}
+}
+
+func Test_Compressor_Hijack(t *testing.T) {
+	compressor := &forge.Compressor{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := w.(http.Pusher).Push("/style.css", nil); err != http.ErrNotSupported {
+				t.Fatalf("push: %v", err)
+			}
+
+			if _, _, err := w.(http.Hijacker).Hijack(); err != nil {
+				t.Fatalf("hijack: %s", err)
+			}
+		}),
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(forge.HeaderAcceptEncoding, forge.EncodingGzip)
+
+	writer := &compressTestWriter{ResponseRecorder: httptest.NewRecorder()}
+	compressor.ServeHTTP(writer, request)
+
+	if !writer.hijacked || len(writer.statuses) != 0 {
+		t.Fatalf("hijacked: %t, statuses: %v", writer.hijacked, writer.statuses)
+	}
+}
+
+func Test_Compressor_Unflushable(t *testing.T) {
+	compressor := &forge.Compressor{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := forge.NewEventStream(w, r); err != forge.ErrStreamingUnsupported {
+				t.Fatalf("Compressor should not add http.Flusher, got: %v", err)
+			}
+		}),
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(forge.HeaderAcceptEncoding, forge.EncodingGzip)
+
+	compressor.ServeHTTP(nonFlushingWriter{httptest.NewRecorder()}, request)
+}
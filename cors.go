@@ -0,0 +1,192 @@
+package forge
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS Header Constants
+const (
+	HeaderAccessControlAllowOrigin      = "Access-Control-Allow-Origin"
+	HeaderAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	HeaderAccessControlAllowMethods     = "Access-Control-Allow-Methods"
+	HeaderAccessControlAllowHeaders     = "Access-Control-Allow-Headers"
+	HeaderAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
+	HeaderAccessControlMaxAge           = "Access-Control-Max-Age"
+	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
+	HeaderAccessControlRequestHeaders   = "Access-Control-Request-Headers"
+)
+
+// CORS adds Cross-Origin Resource Sharing headers to a http.Handler. Preflight
+// requests are answered directly without calling the http.Handler.
+type CORS struct {
+	Handler http.Handler
+	// AllowedOrigins are exact origins like "https://app.example.com", wildcard
+	// subdomains like "https://*.example.com" or "*" for any origin. Origins only
+	// allowed by "*" never receive credentials.
+	AllowedOrigins []string
+	// AllowOrigin decides origins not matched by AllowedOrigins
+	AllowOrigin func(origin string, r *http.Request) bool
+	// AllowedMethods defaults to GET, HEAD and POST
+	AllowedMethods []string
+	// AllowedHeaders are request headers clients may send, "*" allows any
+	AllowedHeaders []string
+	// ExposedHeaders are response headers clients may read
+	ExposedHeaders []string
+	// AllowCredentials allows cookies and authorization for origins that are listed
+	// in AllowedOrigins or accepted by AllowOrigin
+	AllowCredentials bool
+	// MaxAge is how long clients may cache preflight responses
+	MaxAge time.Duration
+}
+
+// ServerHTTP satisfies the http.Handler interface
+func (cors *CORS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get(HeaderOrigin)
+	preflight := r.Method == http.MethodOptions && r.Header.Get(HeaderAccessControlRequestMethod) != ""
+
+	// Responses depend on the Origin even when it is missing or not allowed
+	w.Header().Add(HeaderVary, HeaderOrigin)
+
+	if preflight {
+		w.Header().Add(HeaderVary, HeaderAccessControlRequestMethod)
+		w.Header().Add(HeaderVary, HeaderAccessControlRequestHeaders)
+		cors.servePreflight(w, r, origin)
+		return
+	}
+
+	if match := cors.matchOrigin(origin, r); match != originNotAllowed {
+		cors.setOrigin(w, origin, match)
+
+		if len(cors.ExposedHeaders) > 0 {
+			w.Header().Set(HeaderAccessControlExposeHeaders, strings.Join(cors.ExposedHeaders, ", "))
+		}
+	}
+
+	if cors.Handler != nil {
+		cors.Handler.ServeHTTP(w, r)
+	}
+}
+
+// servePreflight answers a preflight request, leaving out the CORS headers when
+// the request is not allowed so the client rejects it
+func (cors *CORS) servePreflight(w http.ResponseWriter, r *http.Request, origin string) {
+	defer w.WriteHeader(http.StatusNoContent)
+
+	match := cors.matchOrigin(origin, r)
+	if match == originNotAllowed {
+		return
+	}
+
+	method := r.Header.Get(HeaderAccessControlRequestMethod)
+	if !containsFold(cors.allowedMethods(), method) {
+		return
+	}
+
+	requestedHeaders := []string{}
+	for _, requestedHeader := range strings.Split(r.Header.Get(HeaderAccessControlRequestHeaders), ",") {
+		requestedHeader = strings.TrimSpace(requestedHeader)
+		if requestedHeader == "" {
+			continue
+		}
+
+		if !containsFold(cors.AllowedHeaders, "*") && !containsFold(cors.AllowedHeaders, requestedHeader) {
+			return
+		}
+
+		requestedHeaders = append(requestedHeaders, requestedHeader)
+	}
+
+	cors.setOrigin(w, origin, match)
+	w.Header().Set(HeaderAccessControlAllowMethods, strings.Join(cors.allowedMethods(), ", "))
+
+	if len(requestedHeaders) > 0 {
+		w.Header().Set(HeaderAccessControlAllowHeaders, strings.Join(requestedHeaders, ", "))
+	}
+
+	if cors.MaxAge > 0 {
+		w.Header().Set(HeaderAccessControlMaxAge, strconv.Itoa(int(cors.MaxAge/time.Second)))
+	}
+}
+
+type originMatch int
+
+const (
+	originNotAllowed originMatch = iota
+	originAllowedByAny
+	originAllowed
+)
+
+func (cors *CORS) setOrigin(w http.ResponseWriter, origin string, match originMatch) {
+	// Origins only allowed by "*" get the "*" origin, which browsers never combine
+	// with credentials, so any origin can not read credentialed responses
+	if match == originAllowedByAny {
+		w.Header().Set(HeaderAccessControlAllowOrigin, "*")
+		return
+	}
+
+	w.Header().Set(HeaderAccessControlAllowOrigin, origin)
+
+	if cors.AllowCredentials {
+		w.Header().Set(HeaderAccessControlAllowCredentials, "true")
+	}
+}
+
+// matchOrigin checks an origin against AllowedOrigins and AllowOrigin, a "*" in
+// AllowedOrigins is only used when nothing more specific allows the origin
+func (cors *CORS) matchOrigin(origin string, r *http.Request) originMatch {
+	if origin == "" {
+		return originNotAllowed
+	}
+
+	originURL, err := url.Parse(origin)
+	if err != nil || originURL.Scheme == "" || originURL.Host == "" {
+		return originNotAllowed
+	}
+
+	for _, allowedOrigin := range cors.AllowedOrigins {
+		if strings.EqualFold(allowedOrigin, origin) {
+			return originAllowed
+		}
+
+		allowedURL, err := url.Parse(strings.ToLower(allowedOrigin))
+		if err != nil || !strings.HasPrefix(allowedURL.Host, "*.") || !strings.EqualFold(allowedURL.Scheme, originURL.Scheme) {
+			continue
+		}
+
+		if _, ok := matchHostPattern(allowedURL.Host, strings.ToLower(originURL.Host)); ok {
+			return originAllowed
+		}
+	}
+
+	if cors.AllowOrigin != nil && cors.AllowOrigin(origin, r) {
+		return originAllowed
+	}
+
+	if containsFold(cors.AllowedOrigins, "*") {
+		return originAllowedByAny
+	}
+
+	return originNotAllowed
+}
+
+func (cors *CORS) allowedMethods() []string {
+	if len(cors.AllowedMethods) == 0 {
+		return []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+
+	return cors.AllowedMethods
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+
+	return false
+}
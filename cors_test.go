@@ -0,0 +1,168 @@
+package forge_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func Test_CORS(t *testing.T) {
+	handlerCalls := 0
+	router := &forge.Router{}
+	router.Handle("/api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerCalls++
+		w.Write([]byte("OK"))
+	}))
+
+	cors := &forge.CORS{
+		Handler:        router,
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowOrigin: func(origin string, r *http.Request) bool {
+			return origin == "http://localhost:3000"
+		},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPut},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Origin         string
+		RequestMethod  string
+		RequestHeaders string
+		StatusCode     int
+		Headers        map[string]string
+	}{
+		{
+			Name:       "exact origin",
+			Method:     http.MethodGet,
+			Origin:     "https://app.example.com",
+			StatusCode: http.StatusOK,
+			Headers: map[string]string{
+				forge.HeaderAccessControlAllowOrigin:      "https://app.example.com",
+				forge.HeaderAccessControlAllowCredentials: "true",
+				forge.HeaderAccessControlExposeHeaders:    "X-Request-ID",
+			},
+		},
+		{Name: "wildcard subdomain", Method: http.MethodGet, Origin: "https://tenant.example.org", StatusCode: http.StatusOK, Headers: map[string]string{forge.HeaderAccessControlAllowOrigin: "https://tenant.example.org"}},
+		{Name: "wildcard scheme", Method: http.MethodGet, Origin: "http://tenant.example.org", StatusCode: http.StatusOK, Headers: map[string]string{forge.HeaderAccessControlAllowOrigin: ""}},
+		{Name: "wildcard apex", Method: http.MethodGet, Origin: "https://example.org", StatusCode: http.StatusOK, Headers: map[string]string{forge.HeaderAccessControlAllowOrigin: ""}},
+		{Name: "predicate", Method: http.MethodGet, Origin: "http://localhost:3000", StatusCode: http.StatusOK, Headers: map[string]string{forge.HeaderAccessControlAllowOrigin: "http://localhost:3000"}},
+		{Name: "not allowed", Method: http.MethodGet, Origin: "https://evil.example.com", StatusCode: http.StatusOK, Headers: map[string]string{forge.HeaderAccessControlAllowOrigin: ""}},
+		{Name: "same origin", Method: http.MethodGet, StatusCode: http.StatusOK, Headers: map[string]string{forge.HeaderVary: "Origin"}},
+		{
+			Name:           "preflight",
+			Method:         http.MethodOptions,
+			Origin:         "https://app.example.com",
+			RequestMethod:  http.MethodPut,
+			RequestHeaders: "content-type, authorization",
+			StatusCode:     http.StatusNoContent,
+			Headers: map[string]string{
+				forge.HeaderAccessControlAllowOrigin:  "https://app.example.com",
+				forge.HeaderAccessControlAllowMethods: "GET, PUT",
+				forge.HeaderAccessControlAllowHeaders: "content-type, authorization",
+				forge.HeaderAccessControlMaxAge:       "600",
+			},
+		},
+		{Name: "preflight method", Method: http.MethodOptions, Origin: "https://app.example.com", RequestMethod: http.MethodDelete, StatusCode: http.StatusNoContent, Headers: map[string]string{forge.HeaderAccessControlAllowOrigin: ""}},
+		{Name: "preflight header", Method: http.MethodOptions, Origin: "https://app.example.com", RequestMethod: http.MethodGet, RequestHeaders: "x-secret", StatusCode: http.StatusNoContent, Headers: map[string]string{forge.HeaderAccessControlAllowOrigin: ""}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			handlerCalls = 0
+
+			request := httptest.NewRequest(testCase.Method, "/api", nil)
+			if testCase.Origin != "" {
+				request.Header.Set(forge.HeaderOrigin, testCase.Origin)
+			}
+
+			if testCase.RequestMethod != "" {
+				request.Header.Set(forge.HeaderAccessControlRequestMethod, testCase.RequestMethod)
+			}
+
+			if testCase.RequestHeaders != "" {
+				request.Header.Set(forge.HeaderAccessControlRequestHeaders, testCase.RequestHeaders)
+			}
+
+			recorder := httptest.NewRecorder()
+			cors.ServeHTTP(recorder, request)
+
+			if recorder.Code != testCase.StatusCode {
+				t.Fatalf("status: %d expected: %d", recorder.Code, testCase.StatusCode)
+			}
+
+			for header, value := range testCase.Headers {
+				if recorder.Header().Get(header) != value {
+					t.Fatalf("%s: %q expected: %q", header, recorder.Header().Get(header), value)
+				}
+			}
+
+			if preflight := testCase.RequestMethod != ""; preflight == (handlerCalls == 1) {
+				t.Fatalf("handler calls: %d", handlerCalls)
+			}
+		})
+	}
+}
+
+func Test_CORS_AnyOrigin(t *testing.T) {
+	cors := &forge.CORS{
+		Handler:        http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		AllowedOrigins: []string{"*"},
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(forge.HeaderOrigin, "https://anywhere.example.com")
+
+	recorder := httptest.NewRecorder()
+	cors.ServeHTTP(recorder, request)
+
+	if origin := recorder.Header().Get(forge.HeaderAccessControlAllowOrigin); origin != "*" {
+		t.Fatalf("origin: %q", origin)
+	}
+
+}
+
+func Test_CORS_AnyOriginWithCredentials(t *testing.T) {
+	cors := &forge.CORS{
+		Handler:          http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		AllowedOrigins:   []string{"*", "https://app.example.com"},
+		AllowCredentials: true,
+	}
+
+	testCases := []struct {
+		Origin      string
+		AllowOrigin string
+		Credentials string
+	}{
+		{Origin: "https://anywhere.example.com", AllowOrigin: "*"},
+		{Origin: "https://app.example.com", AllowOrigin: "https://app.example.com", Credentials: "true"},
+	}
+
+	for _, testCase := range testCases {
+		for _, method := range []string{http.MethodGet, http.MethodOptions} {
+			request := httptest.NewRequest(method, "/", nil)
+			request.Header.Set(forge.HeaderOrigin, testCase.Origin)
+			if method == http.MethodOptions {
+				request.Header.Set(forge.HeaderAccessControlRequestMethod, http.MethodGet)
+			}
+
+			recorder := httptest.NewRecorder()
+			cors.ServeHTTP(recorder, request)
+
+			if origin := recorder.Header().Get(forge.HeaderAccessControlAllowOrigin); origin != testCase.AllowOrigin {
+				t.Fatalf("%s %s origin: %q expected: %q", method, testCase.Origin, origin, testCase.AllowOrigin)
+			}
+
+			if credentials := recorder.Header().Get(forge.HeaderAccessControlAllowCredentials); credentials != testCase.Credentials {
+				t.Fatalf("%s %s credentials: %q expected: %q", method, testCase.Origin, credentials, testCase.Credentials)
+			}
+		}
+	}
+}
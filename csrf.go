@@ -0,0 +1,260 @@
+package forge
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CSRF Defaults
+const (
+	DefaultCSRFCookieName = "forge_csrf"
+	DefaultCSRFHeader     = "X-CSRF-Token"
+	DefaultCSRFFormField  = "_csrf"
+)
+
+// CSRF Error Codes
+const (
+	ErrorCodeCSRFToken  = "csrf_token_invalid"
+	ErrorCodeCSRFOrigin = "csrf_origin_invalid"
+)
+
+const (
+	csrfContextKey  contextKey = "csrf"
+	sessionCSRFKey             = "_forge_csrf"
+	csrfTokenLength            = 32
+)
+
+// CSRF protects a http.Handler against cross-site request forgery. Unsafe requests
+// must come from the same host or a TrustedOrigin and carry the token from CSRFToken
+// in the HeaderName header or the FormField form field. The token is kept in a cookie
+// (double-submit) or, with UseSession, in the Session (synchronizer token). Failed
+// requests get a 403 error Response.
+type CSRF struct {
+	Handler http.Handler
+	// UseSession keeps the token in the Session, it must be wrapped by a SessionManager
+	UseSession bool
+	// CookieName defaults to DefaultCSRFCookieName
+	CookieName   string
+	CookiePath   string
+	CookieDomain string
+	// CookieSecure marks the cookie secure, it is always secure for TLS requests
+	CookieSecure bool
+	// HeaderName defaults to DefaultCSRFHeader
+	HeaderName string
+	// FormField defaults to DefaultCSRFFormField
+	FormField string
+	// TrustedOrigins are other origins allowed to send requests, like "https://app.example.com".
+	// Requests are same-origin when scheme and host match, with the scheme taken from TLS.
+	TrustedOrigins []string
+	// ExemptPaths are path prefixes that are not checked, like token authenticated APIs
+	ExemptPaths []string
+	// Exempt skips the checks for an http.Request when it returns true
+	Exempt func(r *http.Request) bool
+}
+
+// CSRFToken returns the token to submit with unsafe requests, or an empty string
+// outside of a CSRF. It is masked differently on every call to prevent BREACH.
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfContextKey).([]byte)
+	if len(token) == 0 {
+		return ""
+	}
+
+	masked := make([]byte, csrfTokenLength*2)
+	if _, err := rand.Read(masked[:csrfTokenLength]); err != nil {
+		return ""
+	}
+
+	for i := 0; i < csrfTokenLength; i++ {
+		masked[csrfTokenLength+i] = masked[i] ^ token[i]
+	}
+
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// ServerHTTP satisfies the http.Handler interface
+func (csrf *CSRF) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if csrf.Handler == nil {
+		return
+	}
+
+	token, existing := csrf.token(w, r)
+	if token == nil {
+		RespondError(w, r, NewError(http.StatusInternalServerError, ""))
+		return
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), csrfContextKey, token))
+
+	if isSafeMethod(r.Method) || csrf.exempt(r) {
+		csrf.Handler.ServeHTTP(w, r)
+		return
+	}
+
+	if !csrf.validOrigin(r) {
+		RespondError(w, r, &Error{StatusCode: http.StatusForbidden, Message: "Invalid Origin", Code: ErrorCodeCSRFOrigin})
+		return
+	}
+
+	if !existing || !csrf.validToken(r, token) {
+		RespondError(w, r, &Error{StatusCode: http.StatusForbidden, Message: "Invalid CSRF Token", Code: ErrorCodeCSRFToken})
+		return
+	}
+
+	csrf.Handler.ServeHTTP(w, r)
+}
+
+// token returns the stored token, creating one when there is none yet
+func (csrf *CSRF) token(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	session := Session(r)
+	if csrf.UseSession && session != nil {
+		if token := decodeCSRFToken(session.Get(sessionCSRFKey)); token != nil {
+			return token, true
+		}
+	} else if cookie, err := r.Cookie(csrf.cookieName()); err == nil {
+		if token := decodeCSRFToken(cookie.Value); token != nil {
+			return token, true
+		}
+	}
+
+	token := make([]byte, csrfTokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return nil, false
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(token)
+
+	if csrf.UseSession && session != nil {
+		session.Set(sessionCSRFKey, encoded)
+		return token, false
+	}
+
+	path := csrf.CookiePath
+	if path == "" {
+		path = "/"
+	}
+
+	// The cookie is readable by scripts so they can send it back in the header
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrf.cookieName(),
+		Value:    encoded,
+		Path:     path,
+		Domain:   csrf.CookieDomain,
+		Secure:   csrf.CookieSecure || r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return token, false
+}
+
+func (csrf *CSRF) validToken(r *http.Request, token []byte) bool {
+	headerName := csrf.HeaderName
+	if headerName == "" {
+		headerName = DefaultCSRFHeader
+	}
+
+	formField := csrf.FormField
+	if formField == "" {
+		formField = DefaultCSRFFormField
+	}
+
+	submitted := r.Header.Get(headerName)
+	if submitted == "" {
+		submitted = r.PostFormValue(formField)
+	}
+
+	submittedToken, err := base64.RawURLEncoding.DecodeString(submitted)
+	if err != nil {
+		return false
+	}
+
+	// Masked tokens from CSRFToken are unmasked, raw tokens come from the cookie
+	if len(submittedToken) == csrfTokenLength*2 {
+		for i := 0; i < csrfTokenLength; i++ {
+			submittedToken[csrfTokenLength+i] ^= submittedToken[i]
+		}
+
+		submittedToken = submittedToken[csrfTokenLength:]
+	}
+
+	return subtle.ConstantTimeCompare(submittedToken, token) == 1
+}
+
+// validOrigin checks the Origin header, or the Referer when there is none. HTTPS
+// requests without either are rejected, like browsers would never send them.
+func (csrf *CSRF) validOrigin(r *http.Request) bool {
+	origin := r.Header.Get(HeaderOrigin)
+	if origin == "" || origin == "null" {
+		referer := r.Header.Get("Referer")
+		if referer == "" {
+			return r.TLS == nil && origin == ""
+		}
+
+		origin = referer
+	}
+
+	originURL, err := url.Parse(origin)
+	if err != nil || originURL.Host == "" {
+		return false
+	}
+
+	// The scheme of the http.Request comes from TLS, so behind a TLS terminating
+	// proxy the public origin must be listed in TrustedOrigins
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	if strings.EqualFold(originURL.Scheme, scheme) && strings.EqualFold(originURL.Host, r.Host) {
+		return true
+	}
+
+	for _, trustedOrigin := range csrf.TrustedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(trustedOrigin, "/"), originURL.Scheme+"://"+originURL.Host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (csrf *CSRF) exempt(r *http.Request) bool {
+	for _, exemptPath := range csrf.ExemptPaths {
+		if hasPathPrefix(r.URL.Path, "/"+strings.Trim(exemptPath, "/")) {
+			return true
+		}
+	}
+
+	return csrf.Exempt != nil && csrf.Exempt(r)
+}
+
+func (csrf *CSRF) cookieName() string {
+	if csrf.CookieName == "" {
+		return DefaultCSRFCookieName
+	}
+
+	return csrf.CookieName
+}
+
+func decodeCSRFToken(encoded string) []byte {
+	token, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(token) != csrfTokenLength {
+		return nil
+	}
+
+	return token
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return false
+}
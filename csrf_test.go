@@ -0,0 +1,133 @@
+package forge_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func newCSRFTestHandler(csrf *forge.CSRF) http.Handler {
+	csrf.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(forge.CSRFToken(r)))
+	})
+
+	return csrf
+}
+
+func Test_CSRF_DoubleSubmit(t *testing.T) {
+	handler := newCSRFTestHandler(&forge.CSRF{
+		ExemptPaths:    []string{"/api"},
+		TrustedOrigins: []string{"https://app.example.com"},
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/form", nil))
+
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != forge.DefaultCSRFCookieName || cookies[0].HttpOnly {
+		t.Fatalf("unexpected cookies: %v", cookies)
+	}
+
+	cookie := cookies[0]
+	maskedToken := recorder.Body.String()
+	if maskedToken == "" || maskedToken == cookie.Value {
+		t.Fatalf("token should be masked: %q", maskedToken)
+	}
+
+	testCases := []struct {
+		Name       string
+		Path       string
+		Header     http.Header
+		Form       url.Values
+		NoCookie   bool
+		TLS        bool
+		StatusCode int
+		Code       string
+	}{
+		{Name: "form token", Form: url.Values{"_csrf": {maskedToken}}, StatusCode: http.StatusOK},
+		{Name: "header token", Header: http.Header{"X-Csrf-Token": {maskedToken}}, StatusCode: http.StatusOK},
+		{Name: "raw cookie token", Header: http.Header{"X-Csrf-Token": {cookie.Value}}, StatusCode: http.StatusOK},
+		{Name: "missing token", StatusCode: http.StatusForbidden, Code: forge.ErrorCodeCSRFToken},
+		{Name: "wrong token", Form: url.Values{"_csrf": {strings.Repeat("A", 43)}}, StatusCode: http.StatusForbidden, Code: forge.ErrorCodeCSRFToken},
+		{Name: "missing cookie", Form: url.Values{"_csrf": {maskedToken}}, NoCookie: true, StatusCode: http.StatusForbidden, Code: forge.ErrorCodeCSRFToken},
+		{Name: "same origin", Header: http.Header{"Origin": {"http://example.com"}, "X-Csrf-Token": {maskedToken}}, StatusCode: http.StatusOK},
+		{Name: "same origin tls", Header: http.Header{"Origin": {"https://example.com"}, "X-Csrf-Token": {maskedToken}}, TLS: true, StatusCode: http.StatusOK},
+		{Name: "same host other scheme", Header: http.Header{"Origin": {"https://example.com"}, "X-Csrf-Token": {maskedToken}}, StatusCode: http.StatusForbidden, Code: forge.ErrorCodeCSRFOrigin},
+		{Name: "same host downgraded scheme", Header: http.Header{"Origin": {"http://example.com"}, "X-Csrf-Token": {maskedToken}}, TLS: true, StatusCode: http.StatusForbidden, Code: forge.ErrorCodeCSRFOrigin},
+		{Name: "trusted origin", Header: http.Header{"Origin": {"https://app.example.com"}, "X-Csrf-Token": {maskedToken}}, StatusCode: http.StatusOK},
+		{Name: "cross origin", Header: http.Header{"Origin": {"https://evil.example.com"}, "X-Csrf-Token": {maskedToken}}, StatusCode: http.StatusForbidden, Code: forge.ErrorCodeCSRFOrigin},
+		{Name: "cross origin referer", Header: http.Header{"Referer": {"https://evil.example.com/page"}, "X-Csrf-Token": {maskedToken}}, StatusCode: http.StatusForbidden, Code: forge.ErrorCodeCSRFOrigin},
+		{Name: "null origin", Header: http.Header{"Origin": {"null"}, "X-Csrf-Token": {maskedToken}}, StatusCode: http.StatusForbidden, Code: forge.ErrorCodeCSRFOrigin},
+		{Name: "exempt", Path: "/api/projects", NoCookie: true, StatusCode: http.StatusOK},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			path := testCase.Path
+			if path == "" {
+				path = "/form"
+			}
+
+			request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(testCase.Form.Encode()))
+			request.Header.Set(forge.HeaderContentType, "application/x-www-form-urlencoded")
+			for key, values := range testCase.Header {
+				request.Header[key] = values
+			}
+
+			if !testCase.NoCookie {
+				request.AddCookie(cookie)
+			}
+
+			if testCase.TLS {
+				request.TLS = &tls.ConnectionState{}
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != testCase.StatusCode {
+				t.Fatalf("status: %d expected: %d", recorder.Code, testCase.StatusCode)
+			}
+
+			if testCase.Code != "" && !strings.Contains(recorder.Body.String(), `"code":"`+testCase.Code+`"`) {
+				t.Fatalf("body: %s", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func Test_CSRF_Session(t *testing.T) {
+	handler := &forge.SessionManager{Handler: newCSRFTestHandler(&forge.CSRF{UseSession: true})}
+
+	recorder, cookie := sessionTestRequest(handler, "/form", nil)
+	for _, responseCookie := range recorder.Result().Cookies() {
+		if responseCookie.Name == forge.DefaultCSRFCookieName {
+			t.Fatal("synchronizer tokens should not set a cookie")
+		}
+	}
+
+	post := func(token string) int {
+		request := httptest.NewRequest(http.MethodPost, "/form", strings.NewReader(url.Values{"_csrf": {token}}.Encode()))
+		request.Header.Set(forge.HeaderContentType, "application/x-www-form-urlencoded")
+		request.AddCookie(cookie)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		return recorder.Code
+	}
+
+	if status := post(recorder.Body.String()); status != http.StatusOK {
+		t.Fatalf("valid token: %d", status)
+	}
+
+	otherRecorder, _ := sessionTestRequest(handler, "/form", nil)
+	if status := post(otherRecorder.Body.String()); status != http.StatusForbidden {
+		t.Fatalf("token of another session: %d", status)
+	}
+}
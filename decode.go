@@ -0,0 +1,206 @@
+package forge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodySize is the request body limit used when none is configured
+const DefaultMaxBodySize int64 = 1 << 20
+
+// Decode Error Codes
+const (
+	ErrorCodeUnsupportedMediaType = "unsupported_media_type"
+	ErrorCodeBodyTooLarge         = "body_too_large"
+	ErrorCodeEmptyBody            = "empty_body"
+	ErrorCodeInvalidJSON          = "invalid_json"
+	ErrorCodeInvalidType          = "invalid_type"
+	ErrorCodeUnknownField         = "unknown_field"
+	ErrorCodeTrailingData         = "trailing_data"
+)
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// JSONDecoder decodes and validates JSON request bodies
+type JSONDecoder struct {
+	// MaxBodySize is the maximum number of bytes read, defaults to DefaultMaxBodySize
+	MaxBodySize int64
+	// DisallowUnknownFields rejects objects with keys not present in the target
+	DisallowUnknownFields bool
+}
+
+// DecodeJSON decodes the JSON body of an http.Request into v using the default JSONDecoder
+func DecodeJSON(r *http.Request, v interface{}) error {
+	decoder := &JSONDecoder{}
+
+	return decoder.Decode(r, v)
+}
+
+// Decode decodes the JSON body of an http.Request into v, returning an *Error describing any failure
+func (decoder *JSONDecoder) Decode(r *http.Request, v interface{}) error {
+	if !isJSONContentType(r.Header.Get(HeaderContentType)) {
+		return &Error{
+			StatusCode: http.StatusUnsupportedMediaType,
+			Message:    "Content-Type must be application/json",
+			Code:       ErrorCodeUnsupportedMediaType,
+		}
+	}
+
+	if r.Body == nil {
+		return emptyBodyError()
+	}
+
+	maxBodySize := decoder.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+
+	jsonDecoder := json.NewDecoder(&limitedReader{
+		Reader:    r.Body,
+		Remaining: maxBodySize,
+	})
+	if decoder.DisallowUnknownFields {
+		jsonDecoder.DisallowUnknownFields()
+	}
+
+	if err := jsonDecoder.Decode(v); err != nil {
+		return decodeError(err, maxBodySize)
+	}
+
+	var trailing json.RawMessage
+	if err := jsonDecoder.Decode(&trailing); err != io.EOF {
+		if errors.Is(err, errBodyTooLarge) {
+			return decodeError(err, maxBodySize)
+		}
+
+		return &Error{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Request body must only contain a single JSON value",
+			Code:       ErrorCodeTrailingData,
+			Err:        err,
+		}
+	}
+
+	return nil
+}
+
+func decodeError(err error, maxBodySize int64) error {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	var invalidUnmarshalError *json.InvalidUnmarshalError
+
+	switch {
+	case errors.Is(err, errBodyTooLarge):
+		return &Error{
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Message:    fmt.Sprintf("Request body must not be larger than %d bytes", maxBodySize),
+			Code:       ErrorCodeBodyTooLarge,
+			Err:        err,
+		}
+	case errors.Is(err, io.EOF):
+		return emptyBodyError()
+	case errors.As(err, &syntaxError):
+		return &Error{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("Request body contains badly-formed JSON (at position %d)", syntaxError.Offset),
+			Code:       ErrorCodeInvalidJSON,
+			Err:        err,
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &Error{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Request body contains badly-formed JSON",
+			Code:       ErrorCodeInvalidJSON,
+			Err:        err,
+		}
+	case errors.As(err, &typeError):
+		return &Error{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Request body contains an invalid value",
+			Code:       ErrorCodeInvalidType,
+			Fields: []FieldError{
+				{
+					Field:   typeError.Field,
+					Message: fmt.Sprintf("must be of type %s", typeError.Type),
+					Code:    ErrorCodeInvalidType,
+				},
+			},
+			Err: err,
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+
+		return &Error{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Request body contains an unknown field",
+			Code:       ErrorCodeUnknownField,
+			Fields: []FieldError{
+				{
+					Field:   field,
+					Message: "is not allowed",
+					Code:    ErrorCodeUnknownField,
+				},
+			},
+			Err: err,
+		}
+	case errors.As(err, &invalidUnmarshalError):
+		return &Error{Err: err}
+	default:
+		return &Error{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Request body could not be read",
+			Code:       ErrorCodeInvalidJSON,
+			Err:        err,
+		}
+	}
+}
+
+func emptyBodyError() error {
+	return &Error{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Request body must not be empty",
+		Code:       ErrorCodeEmptyBody,
+	}
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// limitedReader returns errBodyTooLarge instead of io.EOF once the limit is exceeded
+type limitedReader struct {
+	Reader    io.Reader
+	Remaining int64
+}
+
+func (reader *limitedReader) Read(p []byte) (int, error) {
+	if reader.Remaining <= 0 {
+		// Probe for a single byte to tell an exact fit from an overflow
+		var probe [1]byte
+		n, err := reader.Reader.Read(probe[:])
+		if n > 0 {
+			return 0, errBodyTooLarge
+		}
+
+		return 0, err
+	}
+
+	if int64(len(p)) > reader.Remaining {
+		p = p[:reader.Remaining]
+	}
+
+	n, err := reader.Reader.Read(p)
+	reader.Remaining -= int64(n)
+
+	return n, err
+}
@@ -0,0 +1,94 @@
+package forge_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fuzzingbits/forge"
+)
+
+type decodeTestTarget struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func Test_DecodeJSON_Success(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"Aaron","age":30}`))
+	request.Header.Set(forge.HeaderContentType, "application/json; charset=utf-8")
+
+	target := decodeTestTarget{}
+	if err := forge.DecodeJSON(request, &target); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if target.Name != "Aaron" || target.Age != 30 {
+		t.Fatalf("unexpected result: %+v", target)
+	}
+}
+
+func Test_DecodeJSON_Errors(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Decoder     *forge.JSONDecoder
+		ContentType string
+		Body        string
+		StatusCode  int
+		Code        string
+		Field       string
+	}{
+		{Name: "content type", ContentType: "text/plain", Body: `{}`, StatusCode: http.StatusUnsupportedMediaType, Code: forge.ErrorCodeUnsupportedMediaType},
+		{Name: "empty", Body: ``, StatusCode: http.StatusBadRequest, Code: forge.ErrorCodeEmptyBody},
+		{Name: "syntax", Body: `{"name":}`, StatusCode: http.StatusBadRequest, Code: forge.ErrorCodeInvalidJSON},
+		{Name: "truncated", Body: `{"name":"Aaron"`, StatusCode: http.StatusBadRequest, Code: forge.ErrorCodeInvalidJSON},
+		{Name: "type", Body: `{"age":"thirty"}`, StatusCode: http.StatusBadRequest, Code: forge.ErrorCodeInvalidType, Field: "age"},
+		{Name: "trailing", Body: `{"name":"Aaron"}{}`, StatusCode: http.StatusBadRequest, Code: forge.ErrorCodeTrailingData},
+		{Name: "unknown field", Decoder: &forge.JSONDecoder{DisallowUnknownFields: true}, Body: `{"email":"a@b.c"}`, StatusCode: http.StatusBadRequest, Code: forge.ErrorCodeUnknownField, Field: "email"},
+		{Name: "too large", Decoder: &forge.JSONDecoder{MaxBodySize: 8}, Body: `{"name":"Aaron"}`, StatusCode: http.StatusRequestEntityTooLarge, Code: forge.ErrorCodeBodyTooLarge},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			contentType := testCase.ContentType
+			if contentType == "" {
+				contentType = forge.ContentTypeJSON
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(testCase.Body))
+			request.Header.Set(forge.HeaderContentType, contentType)
+
+			decoder := testCase.Decoder
+			if decoder == nil {
+				decoder = &forge.JSONDecoder{}
+			}
+
+			err := decoder.Decode(request, &decodeTestTarget{})
+
+			forgeErr := &forge.Error{}
+			if !errors.As(err, &forgeErr) {
+				t.Fatalf("expected *forge.Error, got: %v", err)
+			}
+
+			if forgeErr.StatusCode != testCase.StatusCode || forgeErr.Code != testCase.Code {
+				t.Fatalf("error: %d %s expected: %d %s", forgeErr.StatusCode, forgeErr.Code, testCase.StatusCode, testCase.Code)
+			}
+
+			if testCase.Field != "" && (len(forgeErr.Fields) != 1 || forgeErr.Fields[0].Field != testCase.Field) {
+				t.Fatalf("fields: %+v expected field: %s", forgeErr.Fields, testCase.Field)
+			}
+		})
+	}
+}
+
+func Test_DecodeJSON_ExactLimit(t *testing.T) {
+	body := `{"name":"Aaron"}`
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	request.Header.Set(forge.HeaderContentType, forge.ContentTypeJSON)
+
+	decoder := &forge.JSONDecoder{MaxBodySize: int64(len(body))}
+	if err := decoder.Decode(request, &decodeTestTarget{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,100 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+var (
+	requestType = reflect.TypeOf(&http.Request{})
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Endpoint adapts a function returning data and an error into a http.Handler
+type Endpoint func(r *http.Request) (interface{}, error)
+
+// ServerHTTP satisfies the http.Handler interface
+func (endpoint Endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := endpoint(r)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	RespondJSON(w, http.StatusOK, Response{
+		Status: true,
+		Data:   data,
+	})
+}
+
+// NewEndpoint adapts a func(*http.Request) (T, error) or a func(*http.Request, In) (T, error)
+// into an Endpoint. In is decoded from the request body with DecodeJSON and may be a
+// struct or a pointer to a struct. NewEndpoint panics if fn has any other signature.
+func NewEndpoint(fn interface{}) Endpoint {
+	fnValue := reflect.ValueOf(fn)
+	fnType := fnValue.Type()
+
+	if err := validateEndpointFunc(fnType); err != nil {
+		panic(fmt.Sprintf("forge: NewEndpoint: %s: %s", fnType, err))
+	}
+
+	return func(r *http.Request) (interface{}, error) {
+		args := []reflect.Value{reflect.ValueOf(r)}
+
+		if fnType.NumIn() == 2 {
+			inputType := fnType.In(1)
+
+			isPointer := inputType.Kind() == reflect.Ptr
+			if isPointer {
+				inputType = inputType.Elem()
+			}
+
+			input := reflect.New(inputType)
+			if err := DecodeJSON(r, input.Interface()); err != nil {
+				return nil, err
+			}
+
+			if !isPointer {
+				input = input.Elem()
+			}
+
+			args = append(args, input)
+		}
+
+		results := fnValue.Call(args)
+
+		if !results[1].IsNil() {
+			return nil, results[1].Interface().(error)
+		}
+
+		return results[0].Interface(), nil
+	}
+}
+
+func validateEndpointFunc(fnType reflect.Type) error {
+	if fnType.Kind() != reflect.Func {
+		return fmt.Errorf("must be a function")
+	}
+
+	if fnType.NumIn() < 1 || fnType.NumIn() > 2 || fnType.In(0) != requestType {
+		return fmt.Errorf("must accept *http.Request and optionally one input")
+	}
+
+	if fnType.NumIn() == 2 {
+		inputType := fnType.In(1)
+		if inputType.Kind() == reflect.Ptr {
+			inputType = inputType.Elem()
+		}
+
+		if inputType.Kind() != reflect.Struct {
+			return fmt.Errorf("input must be a struct or a pointer to a struct")
+		}
+	}
+
+	if fnType.NumOut() != 2 || fnType.Out(1) != errorType {
+		return fmt.Errorf("must return a value and an error")
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package forge_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fuzzingbits/forge"
+)
+
+type endpointTestInput struct {
+	Name string `json:"name"`
+}
+
+type endpointTestOutput struct {
+	Greeting string `json:"greeting"`
+}
+
+func Test_Endpoint_Success(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", forge.Endpoint(func(r *http.Request) (interface{}, error) {
+		return []string{"a", "b"}, nil
+	}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:               router,
+		Request:               request,
+		TargetStatusCode:      http.StatusOK,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusOK, `{"status":true,"message":"","data":["a","b"]}`+"\n"),
+	})
+}
+
+func Test_Endpoint_Error(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", forge.Endpoint(func(r *http.Request) (interface{}, error) {
+		return nil, forge.NewError(http.StatusForbidden, "Nope")
+	}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:               router,
+		Request:               request,
+		TargetStatusCode:      http.StatusForbidden,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusForbidden, `{"status":false,"message":"Nope","data":{}}`+"\n"),
+	})
+}
+
+func Test_NewEndpoint_Typed(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", forge.NewEndpoint(func(r *http.Request) (endpointTestOutput, error) {
+		return endpointTestOutput{Greeting: "Hello"}, nil
+	}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:               router,
+		Request:               request,
+		TargetStatusCode:      http.StatusOK,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusOK, `{"status":true,"message":"","data":{"greeting":"Hello"}}`+"\n"),
+	})
+}
+
+func Test_NewEndpoint_Input(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", forge.NewEndpoint(func(r *http.Request, input *endpointTestInput) (*endpointTestOutput, error) {
+		if input.Name == "" {
+			return nil, errors.New("name is required")
+		}
+
+		return &endpointTestOutput{Greeting: "Hello " + input.Name}, nil
+	}))
+
+	request, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"Aaron"}`))
+	request.Header.Set(forge.HeaderContentType, forge.ContentTypeJSON)
+
+	handlerTest(t, handlerTestCase{
+		Handler:               router,
+		Request:               request,
+		TargetStatusCode:      http.StatusOK,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusOK, `{"status":true,"message":"","data":{"greeting":"Hello Aaron"}}`+"\n"),
+	})
+}
+
+func Test_NewEndpoint_InvalidInput(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", forge.NewEndpoint(func(r *http.Request, input endpointTestInput) (string, error) {
+		return input.Name, nil
+	}))
+
+	request, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":`))
+	request.Header.Set(forge.HeaderContentType, forge.ContentTypeJSON)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          router,
+		Request:          request,
+		TargetStatusCode: http.StatusBadRequest,
+		CustomResponseChecker: statusAndBodyChecker(
+			http.StatusBadRequest,
+			`{"status":false,"message":"Request body contains badly-formed JSON","data":{"code":"invalid_json"}}`+"\n",
+		),
+	})
+}
+
+func Test_NewEndpoint_InvalidSignature(t *testing.T) {
+	invalidFuncs := []interface{}{
+		"not a function",
+		func() (string, error) { return "", nil },
+		func(r *http.Request) string { return "" },
+		func(r *http.Request, name string) (string, error) { return name, nil },
+	}
+
+	for _, invalidFunc := range invalidFuncs {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected panic for %T", invalidFunc)
+				}
+			}()
+
+			forge.NewEndpoint(invalidFunc)
+		}()
+	}
+}
@@ -0,0 +1,124 @@
+package forge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error is an error that can be safely rendered to the client
+type Error struct {
+	// StatusCode is the HTTP status code, defaults to 500
+	StatusCode int
+	// Message is shown to the client, defaults to the status text
+	Message string
+	// Code is a machine-readable error code
+	Code string
+	// Fields holds field-level validation details
+	Fields []FieldError
+	// Err is the internal cause, never shown to the client
+	Err error
+}
+
+// FieldError describes why a single field failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// ErrorData is the Data of a Response built from an Error
+type ErrorData struct {
+	Code   string       `json:"code,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// Problem is an RFC 7807 problem details document built from an Error
+type Problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Code   string       `json:"code,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// NewError creates an Error with a status code and public message
+func NewError(statusCode int, message string) *Error {
+	return &Error{
+		StatusCode: statusCode,
+		Message:    message,
+	}
+}
+
+// Error satisfies the error interface
+func (e *Error) Error() string {
+	message := fmt.Sprintf("%d %s", e.status(), e.message())
+	if e.Code != "" {
+		message += " (" + e.Code + ")"
+	}
+
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+
+	return message
+}
+
+// Unwrap returns the internal cause of the Error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) status() int {
+	if e.StatusCode == 0 {
+		return http.StatusInternalServerError
+	}
+
+	return e.StatusCode
+}
+
+func (e *Error) message() string {
+	if e.Message == "" {
+		return http.StatusText(e.status())
+	}
+
+	return e.Message
+}
+
+// RespondError responds to an http.Request with the Response or Problem for an error
+func RespondError(w http.ResponseWriter, r *http.Request, err error) {
+	forgeErr := &Error{}
+	if !errors.As(err, &forgeErr) {
+		forgeErr = &Error{Err: err}
+	}
+
+	statusCode := forgeErr.status()
+
+	if negotiateMediaType(r, ContentTypeJSON, ContentTypeProblemJSON) == ContentTypeProblemJSON {
+		w.Header().Set(HeaderContentType, ContentTypeProblemJSON)
+		w.WriteHeader(statusCode)
+
+		encoder := json.NewEncoder(w)
+		_ = encoder.Encode(Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(statusCode),
+			Status: statusCode,
+			Detail: forgeErr.message(),
+			Code:   forgeErr.Code,
+			Fields: forgeErr.Fields,
+		})
+
+		return
+	}
+
+	RespondJSON(w, statusCode, Response{
+		Status:  false,
+		Message: forgeErr.message(),
+		Data: ErrorData{
+			Code:   forgeErr.Code,
+			Fields: forgeErr.Fields,
+		},
+	})
+}
@@ -0,0 +1,139 @@
+package forge_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func Test_RespondError_ForgeError(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forge.RespondError(w, r, &forge.Error{
+			StatusCode: http.StatusUnprocessableEntity,
+			Message:    "Invalid User",
+			Code:       "invalid_user",
+			Fields: []forge.FieldError{
+				{Field: "email", Message: "is required"},
+			},
+			Err: errors.New("secret internal detail"),
+		})
+	}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          router,
+		Request:          request,
+		TargetStatusCode: http.StatusUnprocessableEntity,
+		CustomResponseChecker: statusAndBodyChecker(
+			http.StatusUnprocessableEntity,
+			`{"status":false,"message":"Invalid User","data":{"code":"invalid_user","fields":[{"field":"email","message":"is required"}]}}`+"\n",
+		),
+	})
+}
+
+func Test_RespondError_WrappedError(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forge.RespondError(w, r, fmt.Errorf("loading: %w", forge.NewError(http.StatusNotFound, "")))
+	}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          router,
+		Request:          request,
+		TargetStatusCode: http.StatusNotFound,
+		CustomResponseChecker: statusAndBodyChecker(
+			http.StatusNotFound,
+			`{"status":false,"message":"Not Found","data":{}}`+"\n",
+		),
+	})
+}
+
+func Test_RespondError_PlainError(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forge.RespondError(w, r, errors.New("database is down"))
+	}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          router,
+		Request:          request,
+		TargetStatusCode: http.StatusInternalServerError,
+		CustomResponseChecker: statusAndBodyChecker(
+			http.StatusInternalServerError,
+			`{"status":false,"message":"Internal Server Error","data":{}}`+"\n",
+		),
+	})
+}
+
+func Test_RespondError_Problem(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forge.RespondError(w, r, &forge.Error{
+			StatusCode: http.StatusConflict,
+			Message:    "Username is taken",
+			Code:       "username_taken",
+		})
+	}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(forge.HeaderAccept, forge.ContentTypeProblemJSON)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          router,
+		Request:          request,
+		TargetStatusCode: http.StatusConflict,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			if response.Header.Get(forge.HeaderContentType) != forge.ContentTypeProblemJSON {
+				t.Fatalf("content type: %s expected: %s", response.Header.Get(forge.HeaderContentType), forge.ContentTypeProblemJSON)
+			}
+
+			statusAndBodyChecker(
+				http.StatusConflict,
+				`{"type":"about:blank","title":"Conflict","status":409,"detail":"Username is taken","code":"username_taken"}`+"\n",
+			)(t, response)
+		},
+	})
+}
+
+func Test_Error_Error(t *testing.T) {
+	err := &forge.Error{
+		StatusCode: http.StatusBadRequest,
+		Code:       "bad",
+		Err:        errors.New("cause"),
+	}
+
+	if err.Error() != "400 Bad Request (bad): cause" {
+		t.Fatalf("error: %s", err.Error())
+	}
+
+	if !errors.Is(err, err.Err) {
+		t.Fatal("expected error to unwrap to its cause")
+	}
+}
+
+func statusAndBodyChecker(statusCode int, body string) func(t *testing.T, response *http.Response) {
+	return func(t *testing.T, response *http.Response) {
+		if response.StatusCode != statusCode {
+			t.Fatalf("status code: %d expected: %d", response.StatusCode, statusCode)
+		}
+
+		responseBytes, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			t.Fatalf("Body Read Failed: %s", err)
+		}
+
+		if string(responseBytes) != body {
+			t.Fatalf("response body: %s expected: %s", responseBytes, body)
+		}
+	}
+}
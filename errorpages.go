@@ -0,0 +1,88 @@
+package forge
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// DefaultErrorPages is used by Router, Static and Security when they have no ErrorPages of their own
+var DefaultErrorPages = &ErrorPages{}
+
+// ErrorPages renders error responses by status code, negotiating between
+// plain text, HTML and a JSON Response based on the Accept header
+type ErrorPages struct {
+	// Handlers take over the response for their status code
+	Handlers map[int]http.Handler
+	// Templates render the HTML page for their status code
+	Templates map[int]*template.Template
+	// Template renders the HTML page for status codes without their own Template
+	Template *template.Template
+}
+
+// ErrorPage is the data passed to ErrorPages templates
+type ErrorPage struct {
+	StatusCode int
+	StatusText string
+	Path       string
+}
+
+// Serve responds to an http.Request with the error page for a status code,
+// a nil *ErrorPages uses DefaultErrorPages
+func (pages *ErrorPages) Serve(w http.ResponseWriter, r *http.Request, statusCode int) {
+	if pages == nil {
+		pages = DefaultErrorPages
+	}
+
+	if handler, ok := pages.Handlers[statusCode]; ok && handler != nil {
+		handler.ServeHTTP(&defaultStatusWriter{ResponseWriter: w, status: statusCode}, r)
+		return
+	}
+
+	switch negotiateMediaType(r, "text/plain", "text/html", ContentTypeJSON, ContentTypeProblemJSON) {
+	case "text/html":
+		if pageTemplate := pages.template(statusCode); pageTemplate != nil {
+			w.Header().Set(HeaderContentType, "text/html; charset=utf-8")
+			w.WriteHeader(statusCode)
+			_ = pageTemplate.Execute(w, ErrorPage{
+				StatusCode: statusCode,
+				StatusText: http.StatusText(statusCode),
+				Path:       r.URL.Path,
+			})
+
+			return
+		}
+	case ContentTypeJSON, ContentTypeProblemJSON:
+		RespondError(w, r, NewError(statusCode, ""))
+		return
+	}
+
+	RespondText(w, statusCode, []byte(http.StatusText(statusCode)))
+}
+
+func (pages *ErrorPages) template(statusCode int) *template.Template {
+	if pageTemplate, ok := pages.Templates[statusCode]; ok && pageTemplate != nil {
+		return pageTemplate
+	}
+
+	return pages.Template
+}
+
+// defaultStatusWriter uses status for the response when the handler does not write a header
+type defaultStatusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (writer *defaultStatusWriter) WriteHeader(status int) {
+	writer.wroteHeader = true
+	writer.ResponseWriter.WriteHeader(status)
+}
+
+func (writer *defaultStatusWriter) Write(p []byte) (int, error) {
+	if !writer.wroteHeader {
+		writer.WriteHeader(writer.status)
+	}
+
+	return writer.ResponseWriter.Write(p)
+}
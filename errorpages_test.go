@@ -0,0 +1,77 @@
+package forge_test
+
+import (
+	"html/template"
+	"net/http"
+	"testing"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func errorPagesTestPages() *forge.ErrorPages {
+	return &forge.ErrorPages{
+		Handlers: map[int]http.Handler{
+			http.StatusBadRequest: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("Custom Bad Request"))
+			}),
+		},
+		Templates: map[int]*template.Template{
+			http.StatusNotFound: template.Must(template.New("").Parse(`<h1>{{ .Path }} is missing</h1>`)),
+		},
+		Template: template.Must(template.New("").Parse(`<h1>{{ .StatusCode }} {{ .StatusText }}</h1>`)),
+	}
+}
+
+func Test_ErrorPages_Negotiation(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Handler    http.Handler
+		Path       string
+		Accept     string
+		StatusCode int
+		Body       string
+	}{
+		{Name: "router html", Handler: &forge.Router{ErrorPages: errorPagesTestPages()}, Path: "/missing", Accept: "text/html", StatusCode: http.StatusNotFound, Body: "<h1>/missing is missing</h1>"},
+		{Name: "router json", Handler: &forge.Router{ErrorPages: errorPagesTestPages()}, Path: "/missing", Accept: "application/json", StatusCode: http.StatusNotFound, Body: `{"status":false,"message":"Not Found","data":{}}` + "\n"},
+		{Name: "router text", Handler: &forge.Router{ErrorPages: errorPagesTestPages()}, Path: "/missing", Accept: "*/*", StatusCode: http.StatusNotFound, Body: forge.ResponseTextNotFound},
+		{Name: "static handler", Handler: &forge.Static{FileSystem: http.Dir("./test_files"), ErrorPages: errorPagesTestPages()}, Path: "/%2e%2e/go.mod", StatusCode: http.StatusBadRequest, Body: "Custom Bad Request"},
+		{Name: "static html", Handler: &forge.Static{FileSystem: http.Dir("./test_files"), ErrorPages: errorPagesTestPages()}, Path: "/missing.txt", Accept: "text/html", StatusCode: http.StatusNotFound, Body: "<h1>/missing.txt is missing</h1>"},
+		{Name: "security html", Handler: &forge.Security{Guards: []forge.Guard{&securityTestGuard{}}, Handler: http.NotFoundHandler(), ErrorPages: errorPagesTestPages()}, Path: "/", Accept: "text/html", StatusCode: http.StatusUnauthorized, Body: "<h1>401 Unauthorized</h1>"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, testCase.Path, nil)
+			if testCase.Accept != "" {
+				request.Header.Set(forge.HeaderAccept, testCase.Accept)
+			}
+
+			handlerTest(t, handlerTestCase{
+				Handler:               testCase.Handler,
+				Request:               request,
+				TargetStatusCode:      testCase.StatusCode,
+				CustomResponseChecker: statusAndBodyChecker(testCase.StatusCode, testCase.Body),
+			})
+		})
+	}
+}
+
+func Test_ErrorPages_Default(t *testing.T) {
+	defer func(defaultErrorPages *forge.ErrorPages) {
+		forge.DefaultErrorPages = defaultErrorPages
+	}(forge.DefaultErrorPages)
+
+	forge.DefaultErrorPages = &forge.ErrorPages{
+		Template: template.Must(template.New("").Parse(`Themed {{ .StatusCode }}`)),
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(forge.HeaderAccept, "text/html")
+
+	handlerTest(t, handlerTestCase{
+		Handler:               &forge.Router{},
+		Request:               request,
+		TargetStatusCode:      http.StatusNotFound,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusNotFound, "Themed 404"),
+	})
+}
@@ -2,8 +2,22 @@ package forge
 
 // Header Constants
 const (
-	HeaderContentType  = "Content-Type"
-	HeaderCacheControl = "Cache-Control"
+	HeaderAccept             = "Accept"
+	HeaderAcceptEncoding     = "Accept-Encoding"
+	HeaderContentType        = "Content-Type"
+	HeaderContentEncoding    = "Content-Encoding"
+	HeaderContentLength      = "Content-Length"
+	HeaderContentDisposition = "Content-Disposition"
+	HeaderCacheControl       = "Cache-Control"
+	HeaderETag               = "ETag"
+	HeaderVary               = "Vary"
+	HeaderOrigin             = "Origin"
+)
+
+// Content Type Constants
+const (
+	ContentTypeJSON        = "application/json"
+	ContentTypeProblemJSON = "application/problem+json"
 )
 
 // Response Constants
@@ -0,0 +1,186 @@
+package forge
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Form Login Defaults
+const (
+	DefaultFormLoginTargetParameter = "_target"
+	DefaultFormLoginUsernameField   = "username"
+	DefaultFormLoginPasswordField   = "password"
+)
+
+const sessionTargetKey = "_forge_target"
+
+// FormLoginEntryPoint is an EntryPoint redirecting to a login page. The requested URL
+// is remembered in the Session, or in the TargetParameter query parameter of the
+// login URL outside of a SessionManager, so the FormLoginGuard can redirect back.
+type FormLoginEntryPoint struct {
+	// LoginPath is the path of the login page
+	LoginPath string
+	// TargetParameter defaults to DefaultFormLoginTargetParameter
+	TargetParameter string
+	// ErrorPages serves the 401 for requests to the login page itself
+	ErrorPages *ErrorPages
+}
+
+// Start satisfies the EntryPoint interface
+func (entryPoint *FormLoginEntryPoint) Start(w http.ResponseWriter, r *http.Request) {
+	// Redirecting the login page to itself would loop, it must be served outside of Security
+	if r.URL.Path == entryPoint.LoginPath {
+		entryPoint.ErrorPages.Serve(w, r, http.StatusUnauthorized)
+		return
+	}
+
+	loginURL := entryPoint.LoginPath
+
+	// Only safe requests can be replayed after login
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		target := r.URL.RequestURI()
+
+		if session := Session(r); session != nil {
+			session.Set(sessionTargetKey, target)
+		} else {
+			loginURL = addQueryParameter(loginURL, formLoginTargetParameter(entryPoint.TargetParameter), target)
+		}
+	}
+
+	http.Redirect(w, r, loginURL, http.StatusFound)
+}
+
+// FormLoginGuard is a Guard for login form submissions. It supports POST requests to
+// LoginPath, stores the User in the Session with LogIn and redirects back to the URL
+// remembered by the FormLoginEntryPoint. It must be wrapped by a SessionManager.
+type FormLoginGuard struct {
+	// LoginPath is the path the login form is posted to
+	LoginPath string
+	// UsernameField defaults to DefaultFormLoginUsernameField
+	UsernameField string
+	// PasswordField defaults to DefaultFormLoginPasswordField
+	PasswordField string
+	// TargetParameter defaults to DefaultFormLoginTargetParameter
+	TargetParameter string
+	// DefaultTargetPath is used when no safe target was remembered, defaults to "/"
+	DefaultTargetPath string
+	// FailurePath is redirected to with an "error" query parameter, defaults to LoginPath
+	FailurePath string
+	// Users loads the PasswordUser for a username
+	Users UserProvider
+	// Hasher verifies passwords, defaults to a PBKDF2Hasher
+	Hasher        PasswordHasher
+	authenticator passwordAuthenticator
+}
+
+// Supports satisfies the Guard interface
+func (guard *FormLoginGuard) Supports(request *http.Request) bool {
+	return request.Method == http.MethodPost && request.URL.Path == guard.LoginPath
+}
+
+// GetCredentials satisfies the Guard interface
+func (guard *FormLoginGuard) GetCredentials(request *http.Request) interface{} {
+	usernameField := guard.UsernameField
+	if usernameField == "" {
+		usernameField = DefaultFormLoginUsernameField
+	}
+
+	passwordField := guard.PasswordField
+	if passwordField == "" {
+		passwordField = DefaultFormLoginPasswordField
+	}
+
+	return PasswordCredentials{
+		Username: request.PostFormValue(usernameField),
+		Password: request.PostFormValue(passwordField),
+	}
+}
+
+// GetUser satisfies the Guard interface
+func (guard *FormLoginGuard) GetUser(credentials interface{}) (User, error) {
+	return guard.authenticator.getUser(guard.Users, guard.Hasher, credentials)
+}
+
+// CheckCredentials satisfies the Guard interface
+func (guard *FormLoginGuard) CheckCredentials(user interface{}, credentials interface{}) (bool, error) {
+	return guard.authenticator.checkCredentials(guard.Hasher, user, credentials)
+}
+
+// OnAuthenticationFailure satisfies the Guard interface
+func (guard *FormLoginGuard) OnAuthenticationFailure(w http.ResponseWriter, r *http.Request) {
+	failureURL := guard.FailurePath
+	if failureURL == "" {
+		failureURL = guard.LoginPath
+	}
+
+	failureURL = addQueryParameter(failureURL, "error", "1")
+
+	// Keep a target posted by the form so the next attempt can still use it
+	targetParameter := formLoginTargetParameter(guard.TargetParameter)
+	if target := r.PostFormValue(targetParameter); isSafeRedirect(target) {
+		failureURL = addQueryParameter(failureURL, targetParameter, target)
+	}
+
+	http.Redirect(w, r, failureURL, http.StatusSeeOther)
+}
+
+// OnAuthenticationSuccess satisfies the Guard interface
+func (guard *FormLoginGuard) OnAuthenticationSuccess(w http.ResponseWriter, r *http.Request) {
+	if err := LogIn(r, CurrentUser(r)); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	target := Session(r).Get(sessionTargetKey)
+	Session(r).Delete(sessionTargetKey)
+
+	if !isSafeRedirect(target) {
+		target = r.PostFormValue(formLoginTargetParameter(guard.TargetParameter))
+	}
+
+	if !isSafeRedirect(target) {
+		target = guard.DefaultTargetPath
+	}
+
+	if target == "" {
+		target = "/"
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+func formLoginTargetParameter(parameter string) string {
+	if parameter == "" {
+		return DefaultFormLoginTargetParameter
+	}
+
+	return parameter
+}
+
+// isSafeRedirect checks that a target stays on the same origin, rejecting absolute
+// URLs, protocol relative URLs and backslashes browsers treat as slashes
+func isSafeRedirect(target string) bool {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.Contains(target, `\`) {
+		return false
+	}
+
+	for _, char := range target {
+		if char < 0x20 || char == 0x7f {
+			return false
+		}
+	}
+
+	targetURL, err := url.Parse(target)
+
+	return err == nil && targetURL.Scheme == "" && targetURL.Host == ""
+}
+
+func addQueryParameter(rawURL string, key string, value string) string {
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+
+	return rawURL + separator + url.QueryEscape(key) + "=" + url.QueryEscape(value)
+}
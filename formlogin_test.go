@@ -0,0 +1,139 @@
+package forge_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func newFormLoginTestHandler() http.Handler {
+	entryPoint := &forge.FormLoginEntryPoint{LoginPath: "/login"}
+	users := newUserTestProvider()
+
+	app := &forge.Security{
+		EntryPoint: entryPoint,
+		Guards:     []forge.Guard{&forge.SessionGuard{Users: users}},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("Hello " + forge.CurrentUser(r).GetUsername()))
+		}),
+	}
+
+	login := &forge.Security{
+		Guards: []forge.Guard{
+			&forge.FormLoginGuard{LoginPath: "/login", Users: users, Hasher: testPasswordHasher},
+		},
+		Handler: app,
+	}
+
+	router := &forge.Router{}
+	router.Handle("/login", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			login.ServeHTTP(w, r)
+			return
+		}
+
+		w.Write([]byte("Login Page"))
+	}))
+	router.Handle("/admin", app)
+
+	return &forge.SessionManager{Handler: router}
+}
+
+func formLoginTestRequest(handler http.Handler, method string, target string, form url.Values, cookie *http.Cookie) (*httptest.ResponseRecorder, *http.Cookie) {
+	request := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	request.Header.Set(forge.HeaderContentType, "application/x-www-form-urlencoded")
+	if cookie != nil {
+		request.AddCookie(cookie)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	for _, responseCookie := range recorder.Result().Cookies() {
+		if responseCookie.Name == forge.DefaultSessionCookieName {
+			return recorder, responseCookie
+		}
+	}
+
+	return recorder, cookie
+}
+
+func Test_FormLogin(t *testing.T) {
+	handler := newFormLoginTestHandler()
+
+	recorder, cookie := formLoginTestRequest(handler, http.MethodGet, "/admin?tab=users", nil, nil)
+	if recorder.Code != http.StatusFound || recorder.Header().Get("Location") != "/login" {
+		t.Fatalf("entry point: %d %s", recorder.Code, recorder.Header().Get("Location"))
+	}
+
+	recorder, cookie = formLoginTestRequest(handler, http.MethodPost, "/login", url.Values{"username": {"aaron"}, "password": {"wrong"}}, cookie)
+	if recorder.Code != http.StatusSeeOther || recorder.Header().Get("Location") != "/login?error=1" {
+		t.Fatalf("failure: %d %s", recorder.Code, recorder.Header().Get("Location"))
+	}
+
+	recorder, loginCookie := formLoginTestRequest(handler, http.MethodPost, "/login", url.Values{"username": {"aaron"}, "password": {"secret"}}, cookie)
+	if recorder.Code != http.StatusSeeOther || recorder.Header().Get("Location") != "/admin?tab=users" {
+		t.Fatalf("success: %d %s", recorder.Code, recorder.Header().Get("Location"))
+	}
+
+	if loginCookie.Value == cookie.Value {
+		t.Fatal("session ID should rotate on login")
+	}
+
+	if recorder, _ := formLoginTestRequest(handler, http.MethodGet, "/admin", nil, loginCookie); recorder.Body.String() != "Hello aaron" {
+		t.Fatalf("body: %q", recorder.Body.String())
+	}
+}
+
+func Test_FormLogin_Target(t *testing.T) {
+	handler := newFormLoginTestHandler()
+
+	testCases := []struct {
+		Target   string
+		Location string
+	}{
+		{Target: "/admin", Location: "/admin"},
+		{Target: "https://evil.example.com", Location: "/"},
+		{Target: "//evil.example.com", Location: "/"},
+		{Target: `/\evil.example.com`, Location: "/"},
+		{Target: "javascript:alert(1)", Location: "/"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Target, func(t *testing.T) {
+			form := url.Values{"username": {"aaron"}, "password": {"secret"}, "_target": {testCase.Target}}
+
+			recorder, _ := formLoginTestRequest(handler, http.MethodPost, "/login", form, nil)
+			if recorder.Header().Get("Location") != testCase.Location {
+				t.Fatalf("location: %s expected: %s", recorder.Header().Get("Location"), testCase.Location)
+			}
+		})
+	}
+}
+
+func Test_FormLoginEntryPoint(t *testing.T) {
+	security := &forge.Security{
+		EntryPoint: &forge.FormLoginEntryPoint{LoginPath: "/login"},
+		Guards:     []forge.Guard{&securityTestGuard{}},
+		Handler:    http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	}
+
+	recorder, _ := formLoginTestRequest(security, http.MethodGet, "/admin?tab=users", nil, nil)
+	if location := recorder.Header().Get("Location"); location != "/login?_target=%2Fadmin%3Ftab%3Dusers" {
+		t.Fatalf("location: %s", location)
+	}
+
+	recorder, _ = formLoginTestRequest(security, http.MethodPost, "/admin", nil, nil)
+	if location := recorder.Header().Get("Location"); location != "/login" {
+		t.Fatalf("unsafe methods should not be remembered: %s", location)
+	}
+
+	recorder, _ = formLoginTestRequest(security, http.MethodGet, "/login", nil, nil)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("login page should not redirect to itself: %d", recorder.Code)
+	}
+}
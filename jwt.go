@@ -0,0 +1,585 @@
+package forge
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWT Algorithm Constants
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmHS384 = "HS384"
+	JWTAlgorithmHS512 = "HS512"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmES256 = "ES256"
+)
+
+// JWKS Defaults
+const (
+	DefaultJWKSFetchTimeout = 10 * time.Second
+	DefaultJWKSMaxSize      = 1 << 20
+)
+
+// maxJWTNumericDate is 9999-12-31T23:59:59Z, later dates are rejected as malformed
+const maxJWTNumericDate = 253402300799
+
+var jwtAlgorithms = []string{
+	JWTAlgorithmHS256,
+	JWTAlgorithmHS384,
+	JWTAlgorithmHS512,
+	JWTAlgorithmRS256,
+	JWTAlgorithmES256,
+}
+
+var (
+	// ErrJWTMalformed is returned when a token is not a well-formed JWT
+	ErrJWTMalformed = errors.New("jwt malformed")
+
+	// ErrJWTAlgorithm is returned when a token uses an algorithm that is not allowed
+	ErrJWTAlgorithm = errors.New("jwt algorithm not allowed")
+
+	// ErrJWTKeyNotFound is returned when no key matches the kid of a token
+	ErrJWTKeyNotFound = errors.New("jwt key not found")
+
+	// ErrJWTSignature is returned when the signature of a token is invalid
+	ErrJWTSignature = errors.New("jwt signature invalid")
+
+	// ErrJWTExpired is returned when a token is past its exp claim
+	ErrJWTExpired = errors.New("jwt expired")
+
+	// ErrJWTNotYetValid is returned when a token is before its nbf or iat claim
+	ErrJWTNotYetValid = errors.New("jwt not yet valid")
+
+	// ErrJWTClaims is returned when the iss, aud or exp claims do not match
+	ErrJWTClaims = errors.New("jwt claims invalid")
+)
+
+// JWTClaims are the verified claims of a JWT
+type JWTClaims map[string]interface{}
+
+// String returns a string claim
+func (claims JWTClaims) String(name string) string {
+	value, _ := claims[name].(string)
+
+	return value
+}
+
+// Subject returns the sub claim
+func (claims JWTClaims) Subject() string {
+	return claims.String("sub")
+}
+
+// Issuer returns the iss claim
+func (claims JWTClaims) Issuer() string {
+	return claims.String("iss")
+}
+
+// Audience returns the aud claim, which may be a string or a list of strings
+func (claims JWTClaims) Audience() []string {
+	switch audience := claims["aud"].(type) {
+	case string:
+		return []string{audience}
+	case []interface{}:
+		audiences := []string{}
+		for _, value := range audience {
+			if stringValue, ok := value.(string); ok {
+				audiences = append(audiences, stringValue)
+			}
+		}
+
+		return audiences
+	}
+
+	return nil
+}
+
+// Time returns a NumericDate claim like exp, nbf or iat
+func (claims JWTClaims) Time(name string) (time.Time, bool) {
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	seconds, err := number.Float64()
+	if err != nil || !(math.Abs(seconds) <= maxJWTNumericDate) {
+		return time.Time{}, false
+	}
+
+	wholeSeconds, fraction := math.Modf(seconds)
+
+	return time.Unix(int64(wholeSeconds), int64(fraction*float64(time.Second))), true
+}
+
+// JWTUser is the User resolved by JWTGuard when it has no UserFromClaims
+type JWTUser struct {
+	Claims JWTClaims
+}
+
+// GetUsername satisfies the User interface with the sub claim
+func (user *JWTUser) GetUsername() string {
+	return user.Claims.Subject()
+}
+
+// JWK is a single key of a JSON Web Key Set
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Use       string `json:"use,omitempty"`
+	K         string `json:"k,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set document
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet holds JWT verification keys indexed by key ID. Keys are an HMAC
+// secret as []byte, an *rsa.PublicKey or an *ecdsa.PublicKey.
+type KeySet struct {
+	keys  map[string]interface{}
+	mutex sync.RWMutex
+}
+
+// Add registers a key under a key ID, replacing any existing key with that ID
+func (keySet *KeySet) Add(kid string, key interface{}) error {
+	switch key.(type) {
+	case []byte, *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+
+	keySet.mutex.Lock()
+	defer keySet.mutex.Unlock()
+
+	if keySet.keys == nil {
+		keySet.keys = make(map[string]interface{})
+	}
+
+	keySet.keys[kid] = key
+
+	return nil
+}
+
+// Key returns the key for a key ID, an empty key ID matches the only key of the KeySet
+func (keySet *KeySet) Key(kid string) (interface{}, bool) {
+	keySet.mutex.RLock()
+	defer keySet.mutex.RUnlock()
+
+	if key, ok := keySet.keys[kid]; ok {
+		return key, true
+	}
+
+	if kid == "" && len(keySet.keys) == 1 {
+		for _, key := range keySet.keys {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+// ReadJWKS replaces the keys of the KeySet with the ones in a JWKS document,
+// keys of unsupported types or meant for encryption are skipped. Documents with
+// duplicate key IDs, or several keys where one has no key ID, are rejected.
+func (keySet *KeySet) ReadJWKS(r io.Reader) error {
+	document := JWKS{}
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return err
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range document.Keys {
+		if jwk.Use == "enc" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return fmt.Errorf("jwk %q: %w", jwk.KeyID, err)
+		}
+
+		if key == nil {
+			continue
+		}
+
+		if _, exists := keys[jwk.KeyID]; exists {
+			return fmt.Errorf("jwk %q: duplicate key ID", jwk.KeyID)
+		}
+
+		keys[jwk.KeyID] = key
+	}
+
+	if _, exists := keys[""]; exists && len(keys) > 1 {
+		return errors.New("jwk without key ID in a set of several keys")
+	}
+
+	keySet.mutex.Lock()
+	defer keySet.mutex.Unlock()
+
+	keySet.keys = keys
+
+	return nil
+}
+
+// LoadJWKSFile replaces the keys of the KeySet with the ones in a JWKS file
+func (keySet *KeySet) LoadJWKSFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return keySet.ReadJWKS(file)
+}
+
+// FetchJWKS replaces the keys of the KeySet with the JWKS document served at a URL,
+// a nil client uses an http.Client with DefaultJWKSFetchTimeout. Documents larger
+// than DefaultJWKSMaxSize are rejected.
+func (keySet *KeySet) FetchJWKS(client *http.Client, url string) error {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultJWKSFetchTimeout}
+	}
+
+	response, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching jwks: unexpected status %d", response.StatusCode)
+	}
+
+	document, err := ioutil.ReadAll(io.LimitReader(response.Body, DefaultJWKSMaxSize+1))
+	if err != nil {
+		return err
+	}
+
+	if len(document) > DefaultJWKSMaxSize {
+		return errors.New("fetching jwks: document too large")
+	}
+
+	return keySet.ReadJWKS(bytes.NewReader(document))
+}
+
+func (jwk JWK) publicKey() (interface{}, error) {
+	switch jwk.KeyType {
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(jwk.K)
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("rsa exponent too large")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, nil
+		}
+
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+
+	return nil, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decoded) == 0 {
+		return nil, errors.New("empty value")
+	}
+
+	return new(big.Int).SetBytes(decoded), nil
+}
+
+// JWTGuard is a Guard for JWT bearer tokens signed with HS256, HS384, HS512, RS256 or ES256
+type JWTGuard struct {
+	Keys *KeySet
+	// Algorithms that are accepted, defaults to all supported algorithms
+	Algorithms []string
+	// Issuer must match the iss claim when set
+	Issuer string
+	// Audience must be in the aud claim when set
+	Audience string
+	// Leeway is the clock skew tolerated for exp, nbf and iat
+	Leeway time.Duration
+	// UserFromClaims resolves the User for verified claims, defaults to a *JWTUser
+	UserFromClaims func(claims JWTClaims) (User, error)
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Supports satisfies the Guard interface
+func (guard *JWTGuard) Supports(request *http.Request) bool {
+	return strings.Count(bearerToken(request), ".") == 2
+}
+
+// GetCredentials satisfies the Guard interface
+func (guard *JWTGuard) GetCredentials(request *http.Request) interface{} {
+	return bearerToken(request)
+}
+
+// GetUser satisfies the Guard interface
+func (guard *JWTGuard) GetUser(credentials interface{}) (User, error) {
+	token, _ := credentials.(string)
+
+	claims, err := guard.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if guard.UserFromClaims != nil {
+		return guard.UserFromClaims(claims)
+	}
+
+	return &JWTUser{Claims: claims}, nil
+}
+
+// CheckCredentials satisfies the Guard interface, the token was verified by GetUser
+func (guard *JWTGuard) CheckCredentials(user interface{}, credentials interface{}) (bool, error) {
+	return user != nil, nil
+}
+
+// OnAuthenticationFailure satisfies the Guard interface
+func (guard *JWTGuard) OnAuthenticationFailure(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	RespondError(w, r, NewError(http.StatusUnauthorized, ""))
+}
+
+// OnAuthenticationSuccess satisfies the Guard interface
+func (guard *JWTGuard) OnAuthenticationSuccess(w http.ResponseWriter, r *http.Request) {}
+
+// Verify checks the signature and registered claims of a token and returns its claims
+func (guard *JWTGuard) Verify(token string) (JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTMalformed
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+
+	header := jwtHeader{}
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, ErrJWTMalformed
+	}
+
+	if !guard.algorithmAllowed(header.Algorithm) {
+		return nil, ErrJWTAlgorithm
+	}
+
+	if guard.Keys == nil {
+		return nil, ErrJWTKeyNotFound
+	}
+
+	key, ok := guard.Keys.Key(header.KeyID)
+	if !ok {
+		return nil, ErrJWTKeyNotFound
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+
+	if err := verifyJWTSignature(header.Algorithm, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	claimsBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+
+	claims := JWTClaims{}
+	decoder := json.NewDecoder(bytes.NewReader(claimsBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, ErrJWTMalformed
+	}
+
+	if err := guard.validateClaims(claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (guard *JWTGuard) algorithmAllowed(algorithm string) bool {
+	allowed := guard.Algorithms
+	if len(allowed) == 0 {
+		allowed = jwtAlgorithms
+	}
+
+	for _, allowedAlgorithm := range allowed {
+		if allowedAlgorithm == algorithm {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (guard *JWTGuard) validateClaims(claims JWTClaims, now time.Time) error {
+	expiresAt, ok := claims.Time("exp")
+	if !ok {
+		return ErrJWTClaims
+	}
+
+	if !now.Before(expiresAt.Add(guard.Leeway)) {
+		return ErrJWTExpired
+	}
+
+	for _, name := range []string{"nbf", "iat"} {
+		if _, present := claims[name]; !present {
+			continue
+		}
+
+		notBefore, ok := claims.Time(name)
+		if !ok {
+			return ErrJWTClaims
+		}
+
+		if now.Add(guard.Leeway).Before(notBefore) {
+			return ErrJWTNotYetValid
+		}
+	}
+
+	if guard.Issuer != "" && claims.Issuer() != guard.Issuer {
+		return ErrJWTClaims
+	}
+
+	if guard.Audience != "" {
+		for _, audience := range claims.Audience() {
+			if audience == guard.Audience {
+				return nil
+			}
+		}
+
+		return ErrJWTClaims
+	}
+
+	return nil
+}
+
+// verifyJWTSignature checks a signature, the key type must match the algorithm family
+// so a public key can never be used as an HMAC secret
+func verifyJWTSignature(algorithm string, key interface{}, signingInput []byte, signature []byte) error {
+	switch algorithm {
+	case JWTAlgorithmHS256, JWTAlgorithmHS384, JWTAlgorithmHS512:
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrJWTAlgorithm
+		}
+
+		hashFunc := map[string]func() hash.Hash{
+			JWTAlgorithmHS256: sha256.New,
+			JWTAlgorithmHS384: sha512.New384,
+			JWTAlgorithmHS512: sha512.New,
+		}[algorithm]
+
+		mac := hmac.New(hashFunc, secret)
+		mac.Write(signingInput)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrJWTSignature
+		}
+	case JWTAlgorithmRS256:
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrJWTAlgorithm
+		}
+
+		digest := sha256.Sum256(signingInput)
+		if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrJWTSignature
+		}
+	case JWTAlgorithmES256:
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || publicKey.Curve != elliptic.P256() {
+			return ErrJWTAlgorithm
+		}
+
+		if len(signature) != 64 {
+			return ErrJWTSignature
+		}
+
+		digest := sha256.Sum256(signingInput)
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, digest[:], r, s) {
+			return ErrJWTSignature
+		}
+	default:
+		return ErrJWTAlgorithm
+	}
+
+	return nil
+}
+
+func bearerToken(request *http.Request) string {
+	authorization := request.Header.Get("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+
+	return ""
+}
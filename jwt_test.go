@@ -0,0 +1,265 @@
+package forge_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func signJWT(t *testing.T, algorithm string, kid string, key interface{}, claims map[string]interface{}) string {
+	headerBytes, _ := json.Marshal(map[string]string{"alg": algorithm, "kid": kid, "typ": "JWT"})
+	claimsBytes, _ := json.Marshal(claims)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerBytes) + "." + base64.RawURLEncoding.EncodeToString(claimsBytes)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch signingKey := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, signingKey)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, signingKey, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, signingKey, digest[:])
+		if err != nil {
+			t.Fatalf("signing failed: %s", err)
+		}
+
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validJWTClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub": "aaron",
+		"iss": "https://auth.example.com",
+		"aud": []string{"api", "web"},
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func jwtClaimsWith(key string, value interface{}) map[string]interface{} {
+	claims := validJWTClaims()
+	claims[key] = value
+
+	return claims
+}
+
+func Test_JWTGuard_Verify(t *testing.T) {
+	secret := []byte("super-secret")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecdsaKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherECDSAKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	keySet := &forge.KeySet{}
+	keySet.Add("hmac", secret)
+	keySet.Add("rsa", &rsaKey.PublicKey)
+	keySet.Add("ecdsa", &ecdsaKey.PublicKey)
+
+	guard := &forge.JWTGuard{
+		Keys:     keySet,
+		Issuer:   "https://auth.example.com",
+		Audience: "api",
+		Leeway:   time.Minute,
+	}
+
+	testCases := []struct {
+		Name  string
+		Token string
+		Err   error
+	}{
+		{Name: "hs256", Token: signJWT(t, "HS256", "hmac", secret, validJWTClaims())},
+		{Name: "rs256", Token: signJWT(t, "RS256", "rsa", rsaKey, validJWTClaims())},
+		{Name: "es256", Token: signJWT(t, "ES256", "ecdsa", ecdsaKey, validJWTClaims())},
+		{Name: "within leeway", Token: signJWT(t, "HS256", "hmac", secret, jwtClaimsWith("exp", time.Now().Add(-30*time.Second).Unix()))},
+		{Name: "expired", Token: signJWT(t, "HS256", "hmac", secret, jwtClaimsWith("exp", time.Now().Add(-2*time.Minute).Unix())), Err: forge.ErrJWTExpired},
+		{Name: "not before", Token: signJWT(t, "HS256", "hmac", secret, jwtClaimsWith("nbf", time.Now().Add(time.Hour).Unix())), Err: forge.ErrJWTNotYetValid},
+		{Name: "issued in future", Token: signJWT(t, "HS256", "hmac", secret, jwtClaimsWith("iat", time.Now().Add(time.Hour).Unix())), Err: forge.ErrJWTNotYetValid},
+		{Name: "missing exp", Token: signJWT(t, "HS256", "hmac", secret, jwtClaimsWith("exp", nil)), Err: forge.ErrJWTClaims},
+		{Name: "exp out of range", Token: signJWT(t, "HS256", "hmac", secret, jwtClaimsWith("exp", 1e300)), Err: forge.ErrJWTClaims},
+		{Name: "nbf out of range", Token: signJWT(t, "HS256", "hmac", secret, jwtClaimsWith("nbf", 1e300)), Err: forge.ErrJWTClaims},
+		{Name: "issuer", Token: signJWT(t, "HS256", "hmac", secret, jwtClaimsWith("iss", "https://evil.example.com")), Err: forge.ErrJWTClaims},
+		{Name: "audience", Token: signJWT(t, "HS256", "hmac", secret, jwtClaimsWith("aud", "admin")), Err: forge.ErrJWTClaims},
+		{Name: "wrong secret", Token: signJWT(t, "HS256", "hmac", []byte("guess"), validJWTClaims()), Err: forge.ErrJWTSignature},
+		{Name: "wrong ecdsa key", Token: signJWT(t, "ES256", "ecdsa", otherECDSAKey, validJWTClaims()), Err: forge.ErrJWTSignature},
+		{Name: "unknown kid", Token: signJWT(t, "HS256", "missing", secret, validJWTClaims()), Err: forge.ErrJWTKeyNotFound},
+		{Name: "algorithm confusion", Token: signJWT(t, "HS256", "rsa", secret, validJWTClaims()), Err: forge.ErrJWTAlgorithm},
+		{Name: "none", Token: signJWT(t, "none", "hmac", nil, validJWTClaims()), Err: forge.ErrJWTAlgorithm},
+		{Name: "malformed", Token: "not.a.jwt", Err: forge.ErrJWTMalformed},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			claims, err := guard.Verify(testCase.Token)
+			if err != testCase.Err {
+				t.Fatalf("error: %v expected: %v", err, testCase.Err)
+			}
+
+			if err == nil && claims.Subject() != "aaron" {
+				t.Fatalf("subject: %s", claims.Subject())
+			}
+		})
+	}
+}
+
+func Test_JWTClaims_Time(t *testing.T) {
+	testCases := []struct {
+		Value string
+		Time  time.Time
+		OK    bool
+	}{
+		{Value: "1700000000", Time: time.Unix(1700000000, 0), OK: true},
+		{Value: "1700000000.5", Time: time.Unix(1700000000, int64(time.Second/2)), OK: true},
+		{Value: "253402300800"},
+		{Value: "-1e300"},
+	}
+
+	for _, testCase := range testCases {
+		claimTime, ok := forge.JWTClaims{"exp": json.Number(testCase.Value)}.Time("exp")
+		if ok != testCase.OK || !claimTime.Equal(testCase.Time) {
+			t.Fatalf("%s: %v %t", testCase.Value, claimTime, ok)
+		}
+	}
+}
+
+func Test_JWTGuard_Security(t *testing.T) {
+	secret := []byte("super-secret")
+
+	keySet := &forge.KeySet{}
+	keySet.Add("", secret)
+
+	security := &forge.Security{
+		Guards: []forge.Guard{
+			&forge.JWTGuard{Keys: keySet, Algorithms: []string{forge.JWTAlgorithmHS256}},
+		},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := forge.CurrentUser(r).(*forge.JWTUser)
+			w.Write([]byte("Hello " + user.GetUsername() + " from " + user.Claims.Issuer()))
+		}),
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Authorization", "Bearer "+signJWT(t, "HS256", "", secret, validJWTClaims()))
+
+	handlerTest(t, handlerTestCase{
+		Handler:               security,
+		Request:               request,
+		TargetStatusCode:      http.StatusOK,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusOK, "Hello aaron from https://auth.example.com"),
+	})
+
+	request, _ = http.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Authorization", "Bearer "+signJWT(t, "HS256", "", []byte("guess"), validJWTClaims()))
+
+	handlerTest(t, handlerTestCase{
+		Handler:               security,
+		Request:               request,
+		TargetStatusCode:      http.StatusUnauthorized,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusUnauthorized, `{"status":false,"message":"Unauthorized","data":{}}`+"\n"),
+	})
+}
+
+func Test_KeySet_JWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecdsaKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	encode := func(value *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(value.Bytes())
+	}
+
+	jwksBytes, _ := json.Marshal(forge.JWKS{
+		Keys: []forge.JWK{
+			{KeyType: "RSA", KeyID: "rsa-2024", N: encode(rsaKey.N), E: encode(big.NewInt(int64(rsaKey.E)))},
+			{KeyType: "EC", KeyID: "ec-2024", Curve: "P-256", X: encode(ecdsaKey.X), Y: encode(ecdsaKey.Y)},
+			{KeyType: "RSA", KeyID: "encryption", Use: "enc", N: "AQAB", E: "AQAB"},
+		},
+	})
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	ioutil.WriteFile(jwksPath, jwksBytes, 0600)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(jwksBytes)
+	}))
+	defer server.Close()
+
+	fileKeySet := &forge.KeySet{}
+	if err := fileKeySet.LoadJWKSFile(jwksPath); err != nil {
+		t.Fatalf("loading jwks file: %s", err)
+	}
+
+	urlKeySet := &forge.KeySet{}
+	if err := urlKeySet.FetchJWKS(nil, server.URL); err != nil {
+		t.Fatalf("fetching jwks: %s", err)
+	}
+
+	for _, keySet := range []*forge.KeySet{fileKeySet, urlKeySet} {
+		guard := &forge.JWTGuard{Keys: keySet}
+
+		if _, err := guard.Verify(signJWT(t, "RS256", "rsa-2024", rsaKey, validJWTClaims())); err != nil {
+			t.Fatalf("rsa verify: %s", err)
+		}
+
+		if _, err := guard.Verify(signJWT(t, "ES256", "ec-2024", ecdsaKey, validJWTClaims())); err != nil {
+			t.Fatalf("ecdsa verify: %s", err)
+		}
+
+		if _, ok := keySet.Key("encryption"); ok {
+			t.Fatal("encryption keys should be skipped")
+		}
+	}
+}
+
+func Test_KeySet_JWKSRejected(t *testing.T) {
+	secret := base64.RawURLEncoding.EncodeToString([]byte("secret"))
+
+	testCases := []struct {
+		Name string
+		Keys []forge.JWK
+	}{
+		{Name: "duplicate key ID", Keys: []forge.JWK{{KeyType: "oct", KeyID: "a", K: secret}, {KeyType: "oct", KeyID: "a", K: secret}}},
+		{Name: "missing key IDs", Keys: []forge.JWK{{KeyType: "oct", K: secret}, {KeyType: "oct", K: secret}}},
+		{Name: "missing key ID", Keys: []forge.JWK{{KeyType: "oct", KeyID: "a", K: secret}, {KeyType: "oct", K: secret}}},
+	}
+
+	for _, testCase := range testCases {
+		jwksBytes, _ := json.Marshal(forge.JWKS{Keys: testCase.Keys})
+
+		if err := (&forge.KeySet{}).ReadJWKS(bytes.NewReader(jwksBytes)); err == nil {
+			t.Fatalf("%s: expected an error", testCase.Name)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"keys":[],"padding":"` + strings.Repeat("x", forge.DefaultJWKSMaxSize) + `"}`))
+	}))
+	defer server.Close()
+
+	if err := (&forge.KeySet{}).FetchJWKS(nil, server.URL); err == nil {
+		t.Fatal("expected oversized documents to be rejected")
+	}
+}
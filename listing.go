@@ -0,0 +1,138 @@
+package forge
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultListingTemplate renders a Listing as a plain HTML table
+var DefaultListingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Index of {{ .Path }}</title>
+</head>
+<body>
+<h1>Index of {{ .Path }}</h1>
+<table>
+<thead><tr><th>Name</th><th>Size</th><th>Modified</th></tr></thead>
+<tbody>
+{{- if ne .Path "/" }}
+<tr><td><a href="../">../</a></td><td></td><td></td></tr>
+{{- end }}
+{{- range .Entries }}
+<tr><td><a href="{{ .URL }}">{{ .Name }}{{ if .IsDir }}/{{ end }}</a></td><td>{{ if not .IsDir }}{{ .Size }}{{ end }}</td><td>{{ .ModTime.UTC.Format "2006-01-02 15:04:05" }}</td></tr>
+{{- end }}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// Listing describes the contents of a directory served by Static
+type Listing struct {
+	Path    string         `json:"path"`
+	Entries []ListingEntry `json:"entries"`
+}
+
+// ListingEntry is a single file or directory in a Listing
+type ListingEntry struct {
+	Name    string    `json:"name"`
+	URL     string    `json:"url"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	IsDir   bool      `json:"isDir"`
+}
+
+// serveListing renders a Listing for directories without an index.html
+func (static *Static) serveListing(w http.ResponseWriter, r *http.Request) bool {
+	name := path.Clean("/" + r.URL.Path)
+
+	directory, err := static.files().Open(name)
+	if err != nil {
+		return false
+	}
+	defer directory.Close()
+
+	directoryInfo, err := directory.Stat()
+	if err != nil || !directoryInfo.IsDir() {
+		return false
+	}
+
+	if index, _, ok := static.openRegularFile(path.Join(name, "index.html")); ok {
+		index.Close()
+		return false
+	}
+
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		localRedirect(w, r, path.Base(r.URL.Path)+"/", http.StatusMovedPermanently)
+		return true
+	}
+
+	fileInfos, err := directory.Readdir(-1)
+	if err != nil {
+		return false
+	}
+
+	listing := Listing{
+		Path:    name,
+		Entries: []ListingEntry{},
+	}
+
+	for _, fileInfo := range fileInfos {
+		// Hidden files follow the same rule as serving them, so listings never reveal
+		// names that can not be requested
+		if strings.HasPrefix(fileInfo.Name(), ".") && (!static.ListingShowHidden || !static.hiddenFileAllowed(fileInfo.Name())) {
+			continue
+		}
+
+		entryURL := (&url.URL{Path: fileInfo.Name()}).String()
+		if fileInfo.IsDir() {
+			entryURL += "/"
+		}
+
+		listing.Entries = append(listing.Entries, ListingEntry{
+			Name:    fileInfo.Name(),
+			URL:     entryURL,
+			Size:    fileInfo.Size(),
+			ModTime: fileInfo.ModTime(),
+			IsDir:   fileInfo.IsDir(),
+		})
+	}
+
+	sort.Slice(listing.Entries, func(i, j int) bool {
+		if listing.Entries[i].IsDir != listing.Entries[j].IsDir {
+			return listing.Entries[i].IsDir
+		}
+
+		return listing.Entries[i].Name < listing.Entries[j].Name
+	})
+
+	w.Header().Set(HeaderCacheControl, CacheControlNoCache)
+	w.Header().Add(HeaderVary, HeaderAccept)
+
+	if negotiateMediaType(r, "text/html", ContentTypeJSON) == ContentTypeJSON {
+		RespondJSON(w, http.StatusOK, Response{
+			Status: true,
+			Data:   listing,
+		})
+
+		return true
+	}
+
+	listingTemplate := static.ListingTemplate
+	if listingTemplate == nil {
+		listingTemplate = DefaultListingTemplate
+	}
+
+	w.Header().Set(HeaderContentType, "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = listingTemplate.Execute(w, listing)
+
+	return true
+}
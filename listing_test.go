@@ -0,0 +1,131 @@
+package forge_test
+
+import (
+	"encoding/json"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func Test_Static_ListingHTML(t *testing.T) {
+	static := &forge.Static{
+		FileSystem:       http.Dir("./test_files"),
+		DirectoryListing: true,
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/listing", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          static,
+		Request:          request,
+		TargetStatusCode: http.StatusOK,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			if response.Request.URL.Path != "/listing/" {
+				t.Fatalf("expected redirect to /listing/, got: %s", response.Request.URL.Path)
+			}
+
+			responseBytes, _ := ioutil.ReadAll(response.Body)
+			body := string(responseBytes)
+
+			for _, expected := range []string{`href="sub/"`, `href="a%20file.txt"`, `href="b.txt"`} {
+				if !strings.Contains(body, expected) {
+					t.Fatalf("expected %s in listing: %s", expected, body)
+				}
+			}
+
+			if strings.Contains(body, ".hidden") {
+				t.Fatalf("listing should not contain hidden files: %s", body)
+			}
+
+			if strings.Index(body, "sub/") > strings.Index(body, "a%20file.txt") {
+				t.Fatalf("directories should be listed first: %s", body)
+			}
+		},
+	})
+}
+
+func Test_Static_ListingJSON(t *testing.T) {
+	testCases := []struct {
+		Name               string
+		AllowedHiddenFiles []string
+		Entries            string
+	}{
+		{Name: "allowed hidden file", AllowedHiddenFiles: []string{".hidden"}, Entries: "sub,.hidden,a file.txt,b.txt"},
+		{Name: "hidden file not allowed", Entries: "sub,a file.txt,b.txt"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			static := &forge.Static{
+				FileSystem:         http.Dir("./test_files"),
+				DirectoryListing:   true,
+				ListingShowHidden:  true,
+				AllowedHiddenFiles: testCase.AllowedHiddenFiles,
+			}
+
+			request, _ := http.NewRequest(http.MethodGet, "/listing/", nil)
+			request.Header.Set(forge.HeaderAccept, forge.ContentTypeJSON)
+
+			handlerTest(t, handlerTestCase{
+				Handler:          static,
+				Request:          request,
+				TargetStatusCode: http.StatusOK,
+				CustomResponseChecker: func(t *testing.T, response *http.Response) {
+					result := struct {
+						Data forge.Listing `json:"data"`
+					}{}
+
+					if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+						t.Fatalf("decode failed: %s", err)
+					}
+
+					names := []string{}
+					for _, entry := range result.Data.Entries {
+						names = append(names, entry.Name)
+					}
+
+					if strings.Join(names, ",") != testCase.Entries {
+						t.Fatalf("unexpected entries: %v", names)
+					}
+				},
+			})
+		})
+	}
+}
+
+func Test_Static_ListingCustomTemplate(t *testing.T) {
+	static := &forge.Static{
+		FileSystem:       http.Dir("./test_files"),
+		DirectoryListing: true,
+		ListingTemplate:  template.Must(template.New("").Parse(`{{ range .Entries }}{{ .Name }};{{ end }}`)),
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/listing/sub/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:               static,
+		Request:               request,
+		TargetStatusCode:      http.StatusOK,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusOK, "x.txt;"),
+	})
+}
+
+func Test_Static_ListingWithIndex(t *testing.T) {
+	static := &forge.Static{
+		FileSystem:       http.Dir("./test_files"),
+		DirectoryListing: true,
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/directory_with_index/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:               static,
+		Request:               request,
+		TargetStatusCode:      http.StatusOK,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusOK, "Directory With Index\n"),
+	})
+}
@@ -23,8 +23,14 @@ func (logger *Logger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Status:         200,
 	}
 
+	// Only advertise http.Flusher when the wrapped http.ResponseWriter supports it
+	var writer http.ResponseWriter = recorder
+	if _, ok := w.(http.Flusher); ok {
+		writer = &flushingStatusRecorder{statusRecorder: recorder}
+	}
+
 	if logger.Handler != nil {
-		logger.Handler.ServeHTTP(recorder, r)
+		logger.Handler.ServeHTTP(writer, r)
 	}
 
 	logger.Log.Printf(
@@ -45,3 +51,11 @@ func (r *statusRecorder) WriteHeader(status int) {
 	r.Status = status
 	r.ResponseWriter.WriteHeader(status)
 }
+
+type flushingStatusRecorder struct {
+	*statusRecorder
+}
+
+func (r *flushingStatusRecorder) Flush() {
+	r.ResponseWriter.(http.Flusher).Flush()
+}
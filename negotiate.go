@@ -0,0 +1,153 @@
+package forge
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// negotiateMediaType returns the offer preferred by the Accept header of the http.Request
+func negotiateMediaType(r *http.Request, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+
+	if r == nil || r.Header.Get(HeaderAccept) == "" {
+		return offers[0]
+	}
+
+	ranges := parseAccept(r.Header.Get(HeaderAccept))
+
+	bestOffer := ""
+	bestQuality := 0.0
+	for _, offer := range offers {
+		quality := acceptQuality(ranges, offer)
+		if quality > bestQuality {
+			bestOffer = offer
+			bestQuality = quality
+		}
+	}
+
+	return bestOffer
+}
+
+// acceptsMediaType checks if the http.Request explicitly accepts the media type, ignoring */*
+func acceptsMediaType(r *http.Request, mediaType string) bool {
+	if r == nil {
+		return false
+	}
+
+	for _, acceptRange := range parseAccept(r.Header.Get(HeaderAccept)) {
+		if acceptRange.mediaType == "*/*" || acceptRange.quality <= 0 {
+			continue
+		}
+
+		if mediaRangeMatches(acceptRange.mediaType, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseAccept(header string) []acceptRange {
+	ranges := []acceptRange{}
+
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if rawQuality, ok := params["q"]; ok {
+			if parsedQuality, err := strconv.ParseFloat(rawQuality, 64); err == nil {
+				quality = parsedQuality
+			}
+		}
+
+		ranges = append(ranges, acceptRange{
+			mediaType: mediaType,
+			quality:   quality,
+		})
+	}
+
+	return ranges
+}
+
+// acceptQuality finds the quality of the most specific range matching the media type
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	quality := 0.0
+	specificity := -1
+
+	for _, acceptRange := range ranges {
+		if !mediaRangeMatches(acceptRange.mediaType, mediaType) {
+			continue
+		}
+
+		rangeSpecificity := 2
+		if acceptRange.mediaType == "*/*" {
+			rangeSpecificity = 0
+		} else if strings.HasSuffix(acceptRange.mediaType, "/*") {
+			rangeSpecificity = 1
+		}
+
+		if rangeSpecificity > specificity {
+			quality = acceptRange.quality
+			specificity = rangeSpecificity
+		}
+	}
+
+	return quality
+}
+
+func mediaRangeMatches(mediaRange string, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))
+	}
+
+	return false
+}
+
+// negotiateEncoding returns the offer preferred by the Accept-Encoding header of the http.Request
+func negotiateEncoding(r *http.Request, offers ...string) string {
+	ranges := parseAccept(r.Header.Get(HeaderAcceptEncoding))
+
+	bestOffer := ""
+	bestQuality := 0.0
+	for _, offer := range offers {
+		quality := 0.0
+		for _, acceptRange := range ranges {
+			if acceptRange.mediaType == offer {
+				quality = acceptRange.quality
+				break
+			}
+
+			if acceptRange.mediaType == "*" {
+				quality = acceptRange.quality
+			}
+		}
+
+		if quality > bestQuality {
+			bestOffer = offer
+			bestQuality = quality
+		}
+	}
+
+	return bestOffer
+}
@@ -0,0 +1,201 @@
+package forge
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PBKDF2 Defaults
+const (
+	DefaultPBKDF2Iterations = 600000
+	DefaultPBKDF2SaltLength = 16
+	DefaultPBKDF2KeyLength  = 32
+)
+
+const pbkdf2SHA256Prefix = "pbkdf2-sha256"
+
+// ErrInvalidPasswordHash is returned when a password hash can not be parsed
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+// PasswordHasher hashes passwords and verifies passwords against those hashes
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash string, password string) (bool, error)
+}
+
+// PasswordUser is a User that can be authenticated with a password
+type PasswordUser interface {
+	User
+	GetPasswordHash() string
+}
+
+// PasswordCredentials are the credentials of password based Guards
+type PasswordCredentials struct {
+	Username string
+	Password string
+}
+
+// passwordAuthenticator loads and verifies PasswordUsers for password based Guards
+type passwordAuthenticator struct {
+	dummyHash     string
+	dummyHashOnce sync.Once
+}
+
+func (authenticator *passwordAuthenticator) getUser(users UserProvider, hasher PasswordHasher, credentials interface{}) (User, error) {
+	passwordCredentials, ok := credentials.(PasswordCredentials)
+	if !ok || users == nil {
+		return nil, nil
+	}
+
+	user, err := users.LoadUser(passwordCredentials.Username)
+	if err != nil || user == nil {
+		authenticator.burnVerification(hasher, passwordCredentials.Password)
+	}
+
+	return user, err
+}
+
+func (authenticator *passwordAuthenticator) checkCredentials(hasher PasswordHasher, user interface{}, credentials interface{}) (bool, error) {
+	passwordUser, ok := user.(PasswordUser)
+	if !ok {
+		return false, nil
+	}
+
+	passwordCredentials, ok := credentials.(PasswordCredentials)
+	if !ok {
+		return false, nil
+	}
+
+	return defaultPasswordHasher(hasher).Verify(passwordUser.GetPasswordHash(), passwordCredentials.Password)
+}
+
+// burnVerification verifies against a throwaway hash so unknown usernames take as long as known ones
+func (authenticator *passwordAuthenticator) burnVerification(hasher PasswordHasher, password string) {
+	hasher = defaultPasswordHasher(hasher)
+
+	authenticator.dummyHashOnce.Do(func() {
+		authenticator.dummyHash, _ = hasher.Hash("forge-dummy-password")
+	})
+
+	_, _ = hasher.Verify(authenticator.dummyHash, password)
+}
+
+func defaultPasswordHasher(hasher PasswordHasher) PasswordHasher {
+	if hasher == nil {
+		return &PBKDF2Hasher{}
+	}
+
+	return hasher
+}
+
+// PBKDF2Hasher is a PasswordHasher using PBKDF2 with HMAC-SHA256, producing
+// hashes in the form "pbkdf2-sha256$iterations$salt$key"
+type PBKDF2Hasher struct {
+	// Iterations defaults to DefaultPBKDF2Iterations
+	Iterations int
+	// SaltLength defaults to DefaultPBKDF2SaltLength
+	SaltLength int
+	// KeyLength defaults to DefaultPBKDF2KeyLength
+	KeyLength int
+}
+
+// Hash satisfies the PasswordHasher interface
+func (hasher *PBKDF2Hasher) Hash(password string) (string, error) {
+	iterations := hasher.Iterations
+	if iterations <= 0 {
+		iterations = DefaultPBKDF2Iterations
+	}
+
+	saltLength := hasher.SaltLength
+	if saltLength <= 0 {
+		saltLength = DefaultPBKDF2SaltLength
+	}
+
+	keyLength := hasher.KeyLength
+	if keyLength <= 0 {
+		keyLength = DefaultPBKDF2KeyLength
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := pbkdf2SHA256([]byte(password), salt, iterations, keyLength)
+
+	return fmt.Sprintf(
+		"%s$%d$%s$%s",
+		pbkdf2SHA256Prefix,
+		iterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify satisfies the PasswordHasher interface
+func (hasher *PBKDF2Hasher) Verify(hash string, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != pbkdf2SHA256Prefix {
+		return false, ErrInvalidPasswordHash
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false, ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false, ErrInvalidPasswordHash
+	}
+
+	expectedKey, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(expectedKey) == 0 {
+		return false, ErrInvalidPasswordHash
+	}
+
+	key := pbkdf2SHA256([]byte(password), salt, iterations, len(expectedKey))
+
+	return subtle.ConstantTimeCompare(key, expectedKey) == 1, nil
+}
+
+// pbkdf2SHA256 derives a key as defined in RFC 8018 section 5.2
+func pbkdf2SHA256(password []byte, salt []byte, iterations int, keyLength int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLength := prf.Size()
+	blockCount := (keyLength + hashLength - 1) / hashLength
+
+	key := make([]byte, 0, blockCount*hashLength)
+	u := make([]byte, hashLength)
+	for block := 1; block <= blockCount; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		u = prf.Sum(u[:0])
+
+		t := make([]byte, hashLength)
+		copy(t, u)
+
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+
+		key = append(key, t...)
+	}
+
+	return key[:keyLength]
+}
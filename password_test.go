@@ -0,0 +1,48 @@
+package forge_test
+
+import (
+	"testing"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func Test_PBKDF2Hasher_HashAndVerify(t *testing.T) {
+	hasher := &forge.PBKDF2Hasher{Iterations: 1000}
+
+	hash, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if valid, err := hasher.Verify(hash, "correct horse"); err != nil || !valid {
+		t.Fatalf("expected password to verify: %v %s", valid, err)
+	}
+
+	if valid, err := hasher.Verify(hash, "battery staple"); err != nil || valid {
+		t.Fatalf("expected password to fail: %v %s", valid, err)
+	}
+
+	otherHash, _ := hasher.Hash("correct horse")
+	if hash == otherHash {
+		t.Fatal("expected unique salts")
+	}
+}
+
+func Test_PBKDF2Hasher_KnownVector(t *testing.T) {
+	hasher := &forge.PBKDF2Hasher{}
+
+	valid, err := hasher.Verify("pbkdf2-sha256$4096$c2FsdA$xeR41ZKIyEGqUw22hFxMjZYok6ABzk4RpJY4c6qYE0o", "password")
+	if err != nil || !valid {
+		t.Fatalf("expected known vector to verify: %v %s", valid, err)
+	}
+}
+
+func Test_PBKDF2Hasher_InvalidHash(t *testing.T) {
+	hasher := &forge.PBKDF2Hasher{}
+
+	for _, hash := range []string{"", "plain", "bcrypt$1$a$b", "pbkdf2-sha256$x$c2FsdA$AAAA", "pbkdf2-sha256$1$!!$AAAA"} {
+		if _, err := hasher.Verify(hash, "password"); err != forge.ErrInvalidPasswordHash {
+			t.Fatalf("%q expected ErrInvalidPasswordHash, got: %v", hash, err)
+		}
+	}
+}
@@ -2,7 +2,13 @@ package forge
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode"
 )
 
 // Response is a basic response structure
@@ -27,9 +33,85 @@ func RespondHTML(w http.ResponseWriter, statusCode int, body []byte) {
 
 // RespondJSON responds to an http.Request with a JSON body
 func RespondJSON(w http.ResponseWriter, statusCode int, v interface{}) {
-	w.Header().Set(HeaderContentType, "application/json")
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
 	w.WriteHeader(statusCode)
 
 	encoder := json.NewEncoder(w)
 	_ = encoder.Encode(v)
 }
+
+// RespondFile responds to an http.Request with the content of a file to be displayed inline,
+// supporting range and conditional requests like http.ServeContent
+func RespondFile(w http.ResponseWriter, r *http.Request, name string, modTime time.Time, content io.ReadSeeker) {
+	respondContent(w, r, "inline", name, modTime, content)
+}
+
+// RespondAttachment responds to an http.Request with the content of a file to be downloaded
+func RespondAttachment(w http.ResponseWriter, r *http.Request, name string, modTime time.Time, content io.ReadSeeker) {
+	respondContent(w, r, "attachment", name, modTime, content)
+}
+
+func respondContent(w http.ResponseWriter, r *http.Request, disposition string, name string, modTime time.Time, content io.ReadSeeker) {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		name = ""
+	}
+
+	w.Header().Set(HeaderContentDisposition, contentDisposition(disposition, name))
+	http.ServeContent(w, r, name, modTime, content)
+}
+
+// contentDisposition builds a Content-Disposition header value following RFC 6266
+func contentDisposition(disposition string, name string) string {
+	if name == "" {
+		return disposition
+	}
+
+	fallback := &strings.Builder{}
+	needsExtended := false
+	for _, character := range name {
+		switch {
+		case character == '"' || character == '\\':
+			fallback.WriteRune('\\')
+			fallback.WriteRune(character)
+		case character < 0x20 || character == 0x7f:
+			needsExtended = true
+			fallback.WriteRune('_')
+		case character > unicode.MaxASCII:
+			needsExtended = true
+			fallback.WriteRune('_')
+		default:
+			fallback.WriteRune(character)
+		}
+	}
+
+	value := disposition + `; filename="` + fallback.String() + `"`
+	if needsExtended {
+		value += "; filename*=UTF-8''" + encodeRFC5987(name)
+	}
+
+	return value
+}
+
+func encodeRFC5987(value string) string {
+	encoded := &strings.Builder{}
+	for _, b := range []byte(value) {
+		if isRFC5987AttrChar(b) {
+			encoded.WriteByte(b)
+			continue
+		}
+
+		fmt.Fprintf(encoded, "%%%02X", b)
+	}
+
+	return encoded.String()
+}
+
+func isRFC5987AttrChar(b byte) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		return true
+	}
+
+	return strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+}
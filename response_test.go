@@ -2,7 +2,9 @@ package forge_test
 
 import (
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/fuzzingbits/forge"
 )
@@ -21,3 +23,73 @@ func Test_Router_Placeholder(t *testing.T) {
 		TargetBody:       forge.ResponseTextNotFound,
 	})
 }
+
+func Test_RespondAttachment(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forge.RespondAttachment(w, r, "exports/report.csv", time.Now(), strings.NewReader("a,b\n1,2\n"))
+	}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          router,
+		Request:          request,
+		TargetStatusCode: http.StatusOK,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			if response.Header.Get(forge.HeaderContentDisposition) != `attachment; filename="report.csv"` {
+				t.Fatalf("content disposition: %s", response.Header.Get(forge.HeaderContentDisposition))
+			}
+
+			if !strings.HasPrefix(response.Header.Get(forge.HeaderContentType), "text/csv") {
+				t.Fatalf("content type: %s", response.Header.Get(forge.HeaderContentType))
+			}
+
+			statusAndBodyChecker(http.StatusOK, "a,b\n1,2\n")(t, response)
+		},
+	})
+}
+
+func Test_RespondAttachment_UnicodeName(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forge.RespondAttachment(w, r, "résumé \"final\".pdf", time.Time{}, strings.NewReader("%PDF-1.4"))
+	}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          router,
+		Request:          request,
+		TargetStatusCode: http.StatusOK,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			target := `attachment; filename="r_sum_ \"final\".pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9%20%22final%22.pdf`
+			if response.Header.Get(forge.HeaderContentDisposition) != target {
+				t.Fatalf("content disposition: %s expected: %s", response.Header.Get(forge.HeaderContentDisposition), target)
+			}
+		},
+	})
+}
+
+func Test_RespondFile_Range(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forge.RespondFile(w, r, "data.txt", time.Now(), strings.NewReader("0123456789"))
+	}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Range", "bytes=2-5")
+
+	handlerTest(t, handlerTestCase{
+		Handler:          router,
+		Request:          request,
+		TargetStatusCode: http.StatusPartialContent,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			if response.Header.Get(forge.HeaderContentDisposition) != `inline; filename="data.txt"` {
+				t.Fatalf("content disposition: %s", response.Header.Get(forge.HeaderContentDisposition))
+			}
+
+			statusAndBodyChecker(http.StatusPartialContent, "2345")(t, response)
+		},
+	})
+}
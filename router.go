@@ -1,36 +1,83 @@
 package forge
 
 import (
+	"context"
+	"net"
 	"net/http"
+	"net/url"
+	"path"
+	"sort"
 	"strings"
 )
 
+type contextKey string
+
+const subdomainContextKey contextKey = "subdomain"
+
 // Router serves http.Requests for a predefined map of Paths
 type Router struct {
 	NotFoundHander http.Handler
+	ErrorPages     *ErrorPages
 	routes         map[string]http.Handler
+	mounts         []mount
+	hosts          []hostRouter
+}
+
+type mount struct {
+	prefix  string
+	handler http.Handler
+}
+
+type hostRouter struct {
+	pattern string
+	router  *Router
 }
 
 // ServerHTTP satisfies the http.Handler interface
 func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if stripTrailingSlash(w, r) {
+	if matchingHost, subdomain, found := router.matchHost(r.Host); found {
+		if subdomain != "" {
+			r = r.WithContext(context.WithValue(r.Context(), subdomainContextKey, subdomain))
+		}
+
+		matchingHost.router.ServeHTTP(w, r)
 		return
 	}
 
-	matchingRoute, found := router.routes[r.URL.Path]
-	if !found {
-		if router.NotFoundHander != nil {
-			router.NotFoundHander.ServeHTTP(w, r)
-			return
+	if matchingRoute, found := router.routes[r.URL.Path]; found {
+		if matchingRoute != nil {
+			matchingRoute.ServeHTTP(w, r)
 		}
 
-		notFoundHander(w, r)
 		return
 	}
 
-	if matchingRoute != nil {
-		matchingRoute.ServeHTTP(w, r)
+	// Mounts see trailing slashes, like the directories of a Static, but a trailing
+	// slash on a route registered with Handle still redirects to the route
+	if _, found := router.routes[strings.TrimRight(r.URL.Path, "/")]; found && stripTrailingSlash(w, r) {
+		return
+	}
+
+	if matchingMount, found := router.matchMount(r.URL.Path); found {
+		matchingMount.handler.ServeHTTP(w, stripPrefix(r, matchingMount.prefix))
+		return
+	}
+
+	if stripTrailingSlash(w, r) {
+		return
+	}
+
+	if router.NotFoundHander != nil {
+		router.NotFoundHander.ServeHTTP(w, r)
+		return
+	}
+
+	if router.ErrorPages != nil {
+		router.ErrorPages.Serve(w, r, http.StatusNotFound)
+		return
 	}
+
+	notFoundHander(w, r)
 }
 
 // Handle registers a http.Handler to a predefined Path
@@ -42,8 +89,146 @@ func (router *Router) Handle(path string, handler http.Handler) {
 	router.routes[path] = handler
 }
 
+// Mount registers a http.Handler to every Path under a prefix, the prefix is
+// stripped from the http.Request before it is passed to the http.Handler.
+// Routes registered with Handle take precedence and the longest prefix wins.
+func (router *Router) Mount(prefix string, handler http.Handler) {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	for i, existingMount := range router.mounts {
+		if existingMount.prefix == prefix {
+			router.mounts[i].handler = handler
+			return
+		}
+	}
+
+	router.mounts = append(router.mounts, mount{
+		prefix:  prefix,
+		handler: handler,
+	})
+
+	sort.SliceStable(router.mounts, func(i, j int) bool {
+		return len(router.mounts[i].prefix) > len(router.mounts[j].prefix)
+	})
+}
+
+// Host returns the Router for http.Requests to a host, creating it if needed.
+// A pattern like "*.example.com" matches any subdomain, which is available
+// through Subdomain. Exact hosts take precedence over wildcards and requests
+// to any other host are served by the parent Router.
+func (router *Router) Host(pattern string) *Router {
+	pattern = strings.ToLower(pattern)
+
+	for _, existingHost := range router.hosts {
+		if existingHost.pattern == pattern {
+			return existingHost.router
+		}
+	}
+
+	newRouter := &Router{}
+	router.hosts = append(router.hosts, hostRouter{
+		pattern: pattern,
+		router:  newRouter,
+	})
+
+	sort.SliceStable(router.hosts, func(i, j int) bool {
+		iWildcard := strings.HasPrefix(router.hosts[i].pattern, "*.")
+		jWildcard := strings.HasPrefix(router.hosts[j].pattern, "*.")
+		if iWildcard != jWildcard {
+			return jWildcard
+		}
+
+		return len(router.hosts[i].pattern) > len(router.hosts[j].pattern)
+	})
+
+	return newRouter
+}
+
+// Subdomain returns the part of the host matched by the wildcard of a Host pattern
+func Subdomain(r *http.Request) string {
+	subdomain, _ := r.Context().Value(subdomainContextKey).(string)
+
+	return subdomain
+}
+
+func (router *Router) matchHost(host string) (hostRouter, string, bool) {
+	if len(router.hosts) == 0 {
+		return hostRouter{}, "", false
+	}
+
+	host = normalizeHost(host)
+
+	for _, existingHost := range router.hosts {
+		if subdomain, ok := matchHostPattern(existingHost.pattern, host); ok {
+			return existingHost, subdomain, true
+		}
+	}
+
+	return hostRouter{}, "", false
+}
+
+func (router *Router) matchMount(path string) (mount, bool) {
+	for _, existingMount := range router.mounts {
+		if hasPathPrefix(path, existingMount.prefix) {
+			return existingMount, true
+		}
+	}
+
+	return mount{}, false
+}
+
+// normalizeHost removes the port and trailing dot of a host and lowercases it
+func normalizeHost(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// matchHostPattern matches a normalized host against an exact or "*.example.com"
+// pattern, returning the subdomain matched by the wildcard
+func matchHostPattern(pattern string, host string) (string, bool) {
+	if !strings.HasPrefix(pattern, "*.") {
+		return "", host == pattern
+	}
+
+	suffix := strings.TrimPrefix(pattern, "*")
+	if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+		return strings.TrimSuffix(host, suffix), true
+	}
+
+	return "", false
+}
+
+// hasPathPrefix checks if a path is a prefix or below it, "/api" matches "/api/users" but not "/apiary"
+func hasPathPrefix(path string, prefix string) bool {
+	return prefix == "/" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func stripPrefix(r *http.Request, prefix string) *http.Request {
+	if prefix == "/" {
+		return r
+	}
+
+	strippedPath := strings.TrimPrefix(r.URL.Path, prefix)
+	if strippedPath == "" {
+		strippedPath = "/"
+	}
+
+	request := requestWithPath(r, strippedPath)
+	if strippedRawPath := strings.TrimPrefix(r.URL.RawPath, prefix); r.URL.RawPath != "" && strippedRawPath != r.URL.RawPath {
+		request.URL.RawPath = strippedRawPath
+		if request.URL.RawPath == "" {
+			request.URL.RawPath = "/"
+		}
+	}
+
+	return request
+}
+
 func notFoundHander(w http.ResponseWriter, r *http.Request) {
-	RespondText(w, http.StatusNotFound, []byte(ResponseTextNotFound))
+	DefaultErrorPages.Serve(w, r, http.StatusNotFound)
 }
 
 func stripTrailingSlash(w http.ResponseWriter, r *http.Request) bool {
@@ -51,7 +236,11 @@ func stripTrailingSlash(w http.ResponseWriter, r *http.Request) bool {
 		return false
 	}
 
-	http.Redirect(w, r, strings.TrimRight(r.URL.Path, "/"), http.StatusTemporaryRedirect)
+	// The target is relative since a mount may have stripped a prefix from the path,
+	// every trailing slash is one more segment to go up
+	trimmedPath := strings.TrimRight(r.URL.Path, "/")
+	target := strings.Repeat("../", len(r.URL.Path)-len(trimmedPath)) + (&url.URL{Path: path.Base(trimmedPath)}).EscapedPath()
+	localRedirect(w, r, target, http.StatusTemporaryRedirect)
 
 	return true
 }
@@ -162,3 +162,139 @@ func handlerTest(t *testing.T, testCase handlerTestCase) {
 		)
 	}
 }
+
+func Test_Router_Mount(t *testing.T) {
+	router := &forge.Router{}
+	router.Mount("/assets/", &forge.Static{FileSystem: http.Dir("./test_files")})
+	router.Mount("/assets/nested", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("nested " + r.URL.Path))
+	}))
+	router.Handle("/assets/manifest", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("manifest"))
+	}))
+	router.Handle("/api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("api"))
+	}))
+
+	testCases := []struct {
+		Path       string
+		StatusCode int
+		Body       string
+	}{
+		{Path: "/assets/success.txt", StatusCode: http.StatusOK, Body: "Success!\n"},
+		{Path: "/assets/directory_with_index", StatusCode: http.StatusOK, Body: "Directory With Index\n"},
+		{Path: "/assets/missing.txt", StatusCode: http.StatusNotFound, Body: forge.ResponseTextNotFound},
+		{Path: "/assets/nested/file", StatusCode: http.StatusOK, Body: "nested /file"},
+		{Path: "/assets/manifest", StatusCode: http.StatusOK, Body: "manifest"},
+		{Path: "/assetsx/success.txt", StatusCode: http.StatusNotFound, Body: forge.ResponseTextNotFound},
+		{Path: "/api", StatusCode: http.StatusOK, Body: "api"},
+	}
+
+	for _, testCase := range testCases {
+		request, _ := http.NewRequest(http.MethodGet, testCase.Path, nil)
+
+		handlerTest(t, handlerTestCase{
+			Handler:               router,
+			Request:               request,
+			TargetStatusCode:      testCase.StatusCode,
+			CustomResponseChecker: statusAndBodyChecker(testCase.StatusCode, testCase.Body),
+		})
+	}
+}
+
+func Test_Router_MountTrailingSlash(t *testing.T) {
+	router := &forge.Router{}
+	router.Mount("/assets", &forge.Static{FileSystem: http.Dir("./test_files")})
+	router.Handle("/assets/manifest", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("manifest"))
+	}))
+	router.Handle("/api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("api"))
+	}))
+
+	subRouter := &forge.Router{}
+	subRouter.Handle("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	}))
+	router.Mount("/v1", subRouter)
+
+	testCases := []struct {
+		Path       string
+		StatusCode int
+		Location   string
+	}{
+		{Path: "/api/", StatusCode: http.StatusTemporaryRedirect, Location: "/api"},
+		{Path: "/missing/", StatusCode: http.StatusTemporaryRedirect, Location: "/missing"},
+		{Path: "/assets/manifest/", StatusCode: http.StatusTemporaryRedirect, Location: "/assets/manifest"},
+		{Path: "/assets/directory_with_index/", StatusCode: http.StatusOK},
+		{Path: "/v1/users/", StatusCode: http.StatusTemporaryRedirect, Location: "/v1/users"},
+		{Path: "/v1/users//?page=2", StatusCode: http.StatusTemporaryRedirect, Location: "/v1/users?page=2"},
+		{Path: "/v1/missing/", StatusCode: http.StatusTemporaryRedirect, Location: "/v1/missing"},
+	}
+
+	for _, testCase := range testCases {
+		request := httptest.NewRequest(http.MethodGet, testCase.Path, nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != testCase.StatusCode {
+			t.Fatalf("%s status code: %d expected: %d", testCase.Path, recorder.Code, testCase.StatusCode)
+		}
+
+		// Locations are relative, resolve them like a client would
+		location := recorder.Header().Get("Location")
+		if location != "" {
+			locationURL, _ := url.Parse(location)
+			location = request.URL.ResolveReference(locationURL).RequestURI()
+		}
+
+		if location != testCase.Location {
+			t.Fatalf("%s location: %q expected: %q", testCase.Path, location, testCase.Location)
+		}
+	}
+}
+
+func Test_Router_Host(t *testing.T) {
+	router := &forge.Router{}
+	router.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("default"))
+	}))
+
+	router.Host("api.example.com").Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("api"))
+	}))
+
+	tenants := router.Host("*.example.com")
+	tenants.NotFoundHander = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forge.RespondText(w, http.StatusNotFound, []byte("no tenant page"))
+	})
+	tenants.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tenant " + forge.Subdomain(r)))
+	}))
+
+	testCases := []struct {
+		Host       string
+		Path       string
+		StatusCode int
+		Body       string
+	}{
+		{Host: "api.example.com", Path: "/", StatusCode: http.StatusOK, Body: "api"},
+		{Host: "API.example.com:8080", Path: "/", StatusCode: http.StatusOK, Body: "api"},
+		{Host: "acme.example.com", Path: "/", StatusCode: http.StatusOK, Body: "tenant acme"},
+		{Host: "acme.example.com", Path: "/missing", StatusCode: http.StatusNotFound, Body: "no tenant page"},
+		{Host: "example.com", Path: "/", StatusCode: http.StatusOK, Body: "default"},
+		{Host: "other.test", Path: "/", StatusCode: http.StatusOK, Body: "default"},
+	}
+
+	for _, testCase := range testCases {
+		request, _ := http.NewRequest(http.MethodGet, testCase.Path, nil)
+		request.Host = testCase.Host
+
+		handlerTest(t, handlerTestCase{
+			Handler:               router,
+			Request:               request,
+			TargetStatusCode:      testCase.StatusCode,
+			CustomResponseChecker: statusAndBodyChecker(testCase.StatusCode, testCase.Body),
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package forge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// Security Header Constants
+const (
+	HeaderStrictTransportSecurity = "Strict-Transport-Security"
+	HeaderContentSecurityPolicy   = "Content-Security-Policy"
+	HeaderContentTypeOptions      = "X-Content-Type-Options"
+	HeaderFrameOptions            = "X-Frame-Options"
+	HeaderReferrerPolicy          = "Referrer-Policy"
+	HeaderPermissionsPolicy       = "Permissions-Policy"
+)
+
+// Security Header Defaults
+const (
+	DefaultStrictTransportSecurity = "max-age=31536000; includeSubDomains"
+	DefaultContentSecurityPolicy   = "default-src 'self'; script-src 'self' 'nonce-{nonce}'; style-src 'self' 'nonce-{nonce}'; object-src 'none'; base-uri 'self'; frame-ancestors 'none'"
+	DefaultContentTypeOptions      = "nosniff"
+	DefaultFrameOptions            = "DENY"
+	DefaultReferrerPolicy          = "strict-origin-when-cross-origin"
+	DefaultPermissionsPolicy       = "camera=(), microphone=(), geolocation=(), payment=(), usb=()"
+)
+
+// CSPNoncePlaceholder is replaced by the nonce of the http.Request in header values
+const CSPNoncePlaceholder = "{nonce}"
+
+const (
+	cspNonceContextKey      contextKey = "cspNonce"
+	secureHeadersContextKey contextKey = "secureHeaders"
+)
+
+// SecureHeaders sets security headers on every response of a http.Handler. Headers
+// override the defaults, an empty value removes a header. Routes can override them
+// by setting or deleting the header before writing, or with another SecureHeaders,
+// which only applies its own Headers on top of the outer one.
+type SecureHeaders struct {
+	Handler http.Handler
+	Headers map[string]string
+}
+
+// CSPNonce returns the nonce of the Content-Security-Policy for inline scripts and
+// styles, like <script nonce="{{ .Nonce }}">, or an empty string outside of a SecureHeaders
+func CSPNonce(r *http.Request) string {
+	nonce, _ := r.Context().Value(cspNonceContextKey).(string)
+
+	return nonce
+}
+
+// ServerHTTP satisfies the http.Handler interface
+func (secureHeaders *SecureHeaders) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	headers := map[string]string{}
+
+	// Only the outermost SecureHeaders applies the defaults, so nested ones do not
+	// undo the application wide Headers
+	if nested, _ := r.Context().Value(secureHeadersContextKey).(bool); !nested {
+		headers = map[string]string{
+			HeaderStrictTransportSecurity: DefaultStrictTransportSecurity,
+			HeaderContentSecurityPolicy:   DefaultContentSecurityPolicy,
+			HeaderContentTypeOptions:      DefaultContentTypeOptions,
+			HeaderFrameOptions:            DefaultFrameOptions,
+			HeaderReferrerPolicy:          DefaultReferrerPolicy,
+			HeaderPermissionsPolicy:       DefaultPermissionsPolicy,
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), secureHeadersContextKey, true))
+	}
+
+	for name, value := range secureHeaders.Headers {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+
+	nonce := CSPNonce(r)
+
+	for name, value := range headers {
+		if value == "" {
+			w.Header().Del(name)
+			continue
+		}
+
+		if strings.Contains(value, CSPNoncePlaceholder) {
+			// Nested SecureHeaders share the nonce so templates see the one that is sent
+			if nonce == "" {
+				nonce = newCSPNonce()
+				r = r.WithContext(context.WithValue(r.Context(), cspNonceContextKey, nonce))
+			}
+
+			value = strings.Replace(value, CSPNoncePlaceholder, nonce, -1)
+		}
+
+		w.Header().Set(name, value)
+	}
+
+	if secureHeaders.Handler != nil {
+		secureHeaders.Handler.ServeHTTP(w, r)
+	}
+}
+
+func newCSPNonce() string {
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+
+	return base64.StdEncoding.EncodeToString(nonce)
+}
@@ -0,0 +1,110 @@
+package forge_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func Test_SecureHeaders(t *testing.T) {
+	router := &forge.Router{}
+	router.Mount("/files", &forge.Static{FileSystem: http.Dir("./test_files")})
+	router.Handle("/page", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(forge.CSPNonce(r)))
+	}))
+	router.Handle("/embed", &forge.SecureHeaders{
+		Headers: map[string]string{
+			forge.HeaderFrameOptions:          "",
+			forge.HeaderContentSecurityPolicy: "default-src 'self'; script-src 'nonce-{nonce}'; frame-ancestors https://partner.example.com",
+		},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(forge.CSPNonce(r)))
+		}),
+	})
+	router.Handle("/download", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(forge.HeaderReferrerPolicy, "no-referrer")
+	}))
+
+	handler := &forge.SecureHeaders{
+		Handler: router,
+		Headers: map[string]string{"cross-origin-opener-policy": "same-origin"},
+	}
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return recorder
+	}
+
+	recorder := serve("/files/success.txt")
+	expectedHeaders := map[string]string{
+		forge.HeaderStrictTransportSecurity: forge.DefaultStrictTransportSecurity,
+		forge.HeaderContentTypeOptions:      forge.DefaultContentTypeOptions,
+		forge.HeaderFrameOptions:            forge.DefaultFrameOptions,
+		forge.HeaderReferrerPolicy:          forge.DefaultReferrerPolicy,
+		forge.HeaderPermissionsPolicy:       forge.DefaultPermissionsPolicy,
+		"Cross-Origin-Opener-Policy":        "same-origin",
+	}
+
+	for header, value := range expectedHeaders {
+		if recorder.Header().Get(header) != value {
+			t.Fatalf("%s: %q expected: %q", header, recorder.Header().Get(header), value)
+		}
+	}
+
+	recorder = serve("/page")
+	nonce := recorder.Body.String()
+	if nonce == "" || !strings.Contains(recorder.Header().Get(forge.HeaderContentSecurityPolicy), "'nonce-"+nonce+"'") {
+		t.Fatalf("nonce %q not in policy: %s", nonce, recorder.Header().Get(forge.HeaderContentSecurityPolicy))
+	}
+
+	if otherNonce := serve("/page").Body.String(); otherNonce == nonce {
+		t.Fatal("nonces should be unique per request")
+	}
+
+	recorder = serve("/embed")
+	if recorder.Header().Get(forge.HeaderFrameOptions) != "" {
+		t.Fatal("route should remove X-Frame-Options")
+	}
+
+	if policy := recorder.Header().Get(forge.HeaderContentSecurityPolicy); policy != "default-src 'self'; script-src 'nonce-"+recorder.Body.String()+"'; frame-ancestors https://partner.example.com" {
+		t.Fatalf("route policy: %s", policy)
+	}
+
+	if recorder := serve("/download"); recorder.Header().Get(forge.HeaderReferrerPolicy) != "no-referrer" {
+		t.Fatalf("handler override: %s", recorder.Header().Get(forge.HeaderReferrerPolicy))
+	}
+}
+
+func Test_SecureHeaders_Nested(t *testing.T) {
+	handler := &forge.SecureHeaders{
+		Headers: map[string]string{
+			forge.HeaderContentSecurityPolicy:   "default-src 'none'",
+			forge.HeaderStrictTransportSecurity: "",
+		},
+		Handler: &forge.SecureHeaders{
+			Headers: map[string]string{forge.HeaderFrameOptions: ""},
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expectedHeaders := map[string]string{
+		forge.HeaderContentSecurityPolicy:   "default-src 'none'",
+		forge.HeaderStrictTransportSecurity: "",
+		forge.HeaderFrameOptions:            "",
+		forge.HeaderContentTypeOptions:      forge.DefaultContentTypeOptions,
+	}
+
+	for header, value := range expectedHeaders {
+		if recorder.Header().Get(header) != value {
+			t.Fatalf("%s: %q expected: %q", header, recorder.Header().Get(header), value)
+		}
+	}
+}
@@ -1,37 +1,201 @@
 package forge
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+	"strings"
+)
 
-// Security protects a http.Handler
+const (
+	userContextKey                contextKey = "user"
+	authenticationErrorContextKey contextKey = "authenticationError"
+)
+
+// Security protects a http.Handler. The first of the Firewalls matching an
+// http.Request decides how it is authenticated, requests matching none of them
+// use the EntryPoint and Guards of the Security.
 type Security struct {
 	EntryPoint EntryPoint
 	Guards     []Guard
+	Firewalls  []*Firewall
 	Handler    http.Handler
+	ErrorPages *ErrorPages
+}
+
+// Firewall is the authentication configuration for part of an application.
+// Empty matching rules match every http.Request.
+type Firewall struct {
+	// PathPrefix matches a path and everything below it, "/api" matches "/api/users"
+	PathPrefix string
+	// Methods matches any of the methods
+	Methods []string
+	// Host matches a host, "*.example.com" matches any subdomain
+	Host       string
+	EntryPoint EntryPoint
+	Guards     []Guard
+	// Anonymous lets requests no Guard supports through without a User,
+	// a Firewall without Guards is always anonymous
+	Anonymous bool
+}
+
+// Matches checks if an http.Request matches the Firewall
+func (firewall *Firewall) Matches(r *http.Request) bool {
+	if firewall.PathPrefix != "" && !hasPathPrefix(r.URL.Path, "/"+strings.Trim(firewall.PathPrefix, "/")) {
+		return false
+	}
+
+	if firewall.Host != "" {
+		if _, ok := matchHostPattern(strings.ToLower(firewall.Host), normalizeHost(r.Host)); !ok {
+			return false
+		}
+	}
+
+	if len(firewall.Methods) == 0 {
+		return true
+	}
+
+	for _, method := range firewall.Methods {
+		if strings.EqualFold(method, r.Method) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // ServerHTTP satisfies the http.Handler interface
 func (security *Security) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if security.Handler != nil {
+	if security.Handler == nil {
+		return
+	}
+
+	entryPoint, guards, anonymous := security.EntryPoint, security.Guards, len(security.Guards) == 0
+	for _, firewall := range security.Firewalls {
+		if firewall.Matches(r) {
+			entryPoint, guards, anonymous = firewall.EntryPoint, firewall.Guards, firewall.Anonymous || len(firewall.Guards) == 0
+			break
+		}
+	}
+
+	for _, guard := range guards {
+		if !guard.Supports(r) {
+			continue
+		}
+
+		credentials := guard.GetCredentials(r)
+
+		user, err := guard.GetUser(credentials)
+		if err != nil || user == nil {
+			guard.OnAuthenticationFailure(w, withAuthenticationError(r, err))
+			return
+		}
+
+		valid, err := guard.CheckCredentials(user, credentials)
+		if err != nil || !valid {
+			guard.OnAuthenticationFailure(w, withAuthenticationError(r, err))
+			return
+		}
+
+		authenticatedRequest := r.WithContext(context.WithValue(r.Context(), userContextKey, user))
+
+		// A Guard that responds on success, like a login redirect, ends the request
+		writer := &writeDetector{ResponseWriter: w}
+		guard.OnAuthenticationSuccess(writer, authenticatedRequest)
+		if writer.written {
+			return
+		}
+
+		security.Handler.ServeHTTP(w, authenticatedRequest)
+
+		return
+	}
+
+	if anonymous {
 		security.Handler.ServeHTTP(w, r)
+		return
+	}
+
+	if entryPoint != nil {
+		entryPoint.Start(w, r)
+		return
+	}
+
+	security.ErrorPages.Serve(w, r, http.StatusUnauthorized)
+}
+
+// CurrentUser returns the User authenticated by Security for the http.Request
+func CurrentUser(r *http.Request) User {
+	user, _ := r.Context().Value(userContextKey).(User)
+
+	return user
+}
+
+// AuthenticationError returns the error of the Guard that failed to authenticate the
+// http.Request, for use in OnAuthenticationFailure
+func AuthenticationError(r *http.Request) error {
+	err, _ := r.Context().Value(authenticationErrorContextKey).(error)
+
+	return err
+}
+
+func withAuthenticationError(r *http.Request, err error) *http.Request {
+	if err == nil {
+		return r
 	}
+
+	return r.WithContext(context.WithValue(r.Context(), authenticationErrorContextKey, err))
 }
 
-// EntryPoint defined the behavior when authentication is not present but required
+// EntryPoint defines the behavior when authentication is not present but required,
+// like challenging the client or redirecting to a login page
 type EntryPoint interface {
-	Start(request http.Request)
+	Start(w http.ResponseWriter, r *http.Request)
+}
+
+// EntryPointFunc is a function EntryPoint
+type EntryPointFunc func(w http.ResponseWriter, r *http.Request)
+
+// Start satisfies the EntryPoint interface
+func (entryPoint EntryPointFunc) Start(w http.ResponseWriter, r *http.Request) {
+	entryPoint(w, r)
 }
 
-// Guard that will attempt to authenticate a user
+// Guard that will attempt to authenticate a user. Both callbacks receive the
+// http.Request so a Guard can redirect or negotiate the response format. When
+// OnAuthenticationSuccess writes a response the protected http.Handler is skipped.
 type Guard interface {
 	Supports(request *http.Request) bool
 	GetCredentials(request *http.Request) interface{}
 	GetUser(credentials interface{}) (User, error)
 	CheckCredentials(user interface{}, credentials interface{}) (bool, error)
-	OnAuthenticationFailure(w http.ResponseWriter)
-	OnAuthenticationSuccess(w http.ResponseWriter)
+	OnAuthenticationFailure(w http.ResponseWriter, r *http.Request)
+	OnAuthenticationSuccess(w http.ResponseWriter, r *http.Request)
 }
 
 // User to be authenticated
 type User interface {
 	GetUsername() string
 }
+
+// UserProvider loads a User by username for Guards
+type UserProvider interface {
+	LoadUser(username string) (User, error)
+}
+
+// writeDetector records whether OnAuthenticationSuccess started a response, in
+// which case Security does not call the wrapped http.Handler
+type writeDetector struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (writer *writeDetector) WriteHeader(status int) {
+	writer.written = true
+	writer.ResponseWriter.WriteHeader(status)
+}
+
+func (writer *writeDetector) Write(p []byte) (int, error) {
+	writer.written = true
+
+	return writer.ResponseWriter.Write(p)
+}
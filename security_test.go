@@ -2,6 +2,7 @@ package forge_test
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/fuzzingbits/forge"
@@ -21,3 +22,170 @@ func Test_Security_Placeholder(t *testing.T) {
 		TargetBody:       forge.ResponseTextNotFound,
 	})
 }
+
+type securityTestUser struct {
+	Username string
+}
+
+func (user *securityTestUser) GetUsername() string {
+	return user.Username
+}
+
+// securityTestGuard authenticates requests with an X-Test-User header, "bad" fails
+// and "welcome" is answered by OnAuthenticationSuccess
+type securityTestGuard struct{}
+
+func (guard *securityTestGuard) Supports(request *http.Request) bool {
+	return request.Header.Get("X-Test-User") != ""
+}
+
+func (guard *securityTestGuard) GetCredentials(request *http.Request) interface{} {
+	return request.Header.Get("X-Test-User")
+}
+
+func (guard *securityTestGuard) GetUser(credentials interface{}) (forge.User, error) {
+	return &securityTestUser{Username: credentials.(string)}, nil
+}
+
+func (guard *securityTestGuard) CheckCredentials(user interface{}, credentials interface{}) (bool, error) {
+	return credentials != "bad", nil
+}
+
+func (guard *securityTestGuard) OnAuthenticationFailure(w http.ResponseWriter, r *http.Request) {
+	forge.RespondText(w, http.StatusForbidden, []byte("Bad User"))
+}
+
+func (guard *securityTestGuard) OnAuthenticationSuccess(w http.ResponseWriter, r *http.Request) {
+	if forge.CurrentUser(r).GetUsername() == "welcome" {
+		forge.RespondText(w, http.StatusOK, []byte("Welcome"))
+	}
+}
+
+func Test_Security_Guards(t *testing.T) {
+	security := &forge.Security{
+		Guards: []forge.Guard{&securityTestGuard{}},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("Hello " + forge.CurrentUser(r).GetUsername()))
+		}),
+	}
+
+	testCases := []struct {
+		User       string
+		StatusCode int
+		Body       string
+	}{
+		{User: "aaron", StatusCode: http.StatusOK, Body: "Hello aaron"},
+		{User: "bad", StatusCode: http.StatusForbidden, Body: "Bad User"},
+		{User: "welcome", StatusCode: http.StatusOK, Body: "Welcome"},
+		{User: "", StatusCode: http.StatusUnauthorized, Body: http.StatusText(http.StatusUnauthorized)},
+	}
+
+	for _, testCase := range testCases {
+		request, _ := http.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("X-Test-User", testCase.User)
+
+		handlerTest(t, handlerTestCase{
+			Handler:               security,
+			Request:               request,
+			TargetStatusCode:      testCase.StatusCode,
+			CustomResponseChecker: statusAndBodyChecker(testCase.StatusCode, testCase.Body),
+		})
+	}
+}
+
+func Test_Security_Firewalls(t *testing.T) {
+	tokens := &forge.MemoryTokenStore{}
+	tokens.Add("api-key", forge.Token{User: &securityTestUser{Username: "robot"}})
+
+	users := newUserTestProvider()
+
+	security := &forge.Security{
+		Guards: []forge.Guard{&securityTestGuard{}},
+		Firewalls: []*forge.Firewall{
+			{
+				PathPrefix: "/api",
+				Guards:     []forge.Guard{&forge.TokenGuard{Store: tokens}},
+				EntryPoint: forge.EntryPointFunc(func(w http.ResponseWriter, r *http.Request) {
+					forge.RespondError(w, r, forge.NewError(http.StatusUnauthorized, ""))
+				}),
+			},
+			{
+				PathPrefix: "/login",
+				Guards:     []forge.Guard{&forge.FormLoginGuard{LoginPath: "/login", Users: users, Hasher: testPasswordHasher}},
+				Anonymous:  true,
+			},
+			{
+				PathPrefix: "/admin",
+				Guards:     []forge.Guard{&forge.SessionGuard{Users: users}},
+				EntryPoint: &forge.FormLoginEntryPoint{LoginPath: "/login"},
+			},
+			{PathPrefix: "/public"},
+			{Host: "*.internal.example.com", Methods: []string{http.MethodGet}},
+		},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user := forge.CurrentUser(r); user != nil {
+				w.Write([]byte("Hello " + user.GetUsername()))
+				return
+			}
+
+			w.Write([]byte("Hello anonymous"))
+		}),
+	}
+
+	handler := &forge.SessionManager{Handler: security}
+
+	testCases := []struct {
+		Name       string
+		Method     string
+		Host       string
+		Path       string
+		Header     http.Header
+		StatusCode int
+		Body       string
+		Location   string
+	}{
+		{Name: "api token", Path: "/api/projects", Header: http.Header{"Authorization": {"Bearer api-key"}}, StatusCode: http.StatusOK, Body: "Hello robot"},
+		{Name: "api without token", Path: "/api/projects", StatusCode: http.StatusUnauthorized, Body: `{"status":false,"message":"Unauthorized","data":{}}` + "\n"},
+		{Name: "api ignores other guards", Path: "/api", Header: http.Header{"X-Test-User": {"aaron"}}, StatusCode: http.StatusUnauthorized},
+		{Name: "login page", Path: "/login", StatusCode: http.StatusOK, Body: "Hello anonymous"},
+		{Name: "admin redirects", Path: "/admin/users", StatusCode: http.StatusFound, Location: "/login"},
+		{Name: "public", Path: "/public/logo.png", StatusCode: http.StatusOK, Body: "Hello anonymous"},
+		{Name: "prefix boundary", Path: "/publication", StatusCode: http.StatusUnauthorized},
+		{Name: "internal host", Host: "metrics.internal.example.com", Path: "/", StatusCode: http.StatusOK, Body: "Hello anonymous"},
+		{Name: "internal host method", Method: http.MethodPost, Host: "metrics.internal.example.com", Path: "/", StatusCode: http.StatusUnauthorized},
+		{Name: "default guards", Path: "/", Header: http.Header{"X-Test-User": {"aaron"}}, StatusCode: http.StatusOK, Body: "Hello aaron"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			method := testCase.Method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			request := httptest.NewRequest(method, testCase.Path, nil)
+			if testCase.Host != "" {
+				request.Host = testCase.Host
+			}
+
+			for key, values := range testCase.Header {
+				request.Header[key] = values
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != testCase.StatusCode {
+				t.Fatalf("status: %d expected: %d", recorder.Code, testCase.StatusCode)
+			}
+
+			if testCase.Body != "" && recorder.Body.String() != testCase.Body {
+				t.Fatalf("body: %q expected: %q", recorder.Body.String(), testCase.Body)
+			}
+
+			if location := recorder.Header().Get("Location"); location != testCase.Location {
+				t.Fatalf("location: %q expected: %q", location, testCase.Location)
+			}
+		})
+	}
+}
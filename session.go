@@ -0,0 +1,439 @@
+package forge
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Session Defaults
+const (
+	DefaultSessionCookieName      = "forge_session"
+	DefaultSessionIdleTimeout     = 30 * time.Minute
+	DefaultSessionAbsoluteTimeout = 24 * time.Hour
+)
+
+const (
+	sessionContextKey contextKey = "session"
+	sessionUserKey               = "_forge_user"
+)
+
+// sessionTouchDivisor limits how often unmodified sessions are saved only to extend
+// their idle timeout, to once per tenth of the IdleTimeout
+const sessionTouchDivisor = 10
+
+// ErrSessionNotFound is returned by a SessionStore that does not know a session
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionRecord is the state of a session persisted by a SessionStore
+type SessionRecord struct {
+	ID        string            `json:"id"`
+	Values    map[string]string `json:"values"`
+	CreatedAt time.Time         `json:"createdAt"`
+	LastSeen  time.Time         `json:"lastSeen"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// SessionStore persists SessionRecords between http.Requests
+type SessionStore interface {
+	// Load returns the SessionRecord for a cookie value
+	Load(cookieValue string) (*SessionRecord, error)
+	// Save persists a SessionRecord and returns the cookie value identifying it
+	Save(record *SessionRecord) (string, error)
+	// Delete removes the SessionRecord with an ID
+	Delete(id string) error
+}
+
+// SessionManager provides a SessionData to every http.Request through Session.
+// It must wrap any Security using a SessionGuard.
+type SessionManager struct {
+	Handler http.Handler
+	// Store persists sessions, defaults to a MemorySessionStore
+	Store SessionStore
+	// CookieName defaults to DefaultSessionCookieName
+	CookieName   string
+	CookiePath   string
+	CookieDomain string
+	// CookieSecure marks the cookie secure, it is always secure for TLS requests
+	CookieSecure bool
+	// CookieSameSite defaults to http.SameSiteLaxMode
+	CookieSameSite http.SameSite
+	// IdleTimeout ends sessions without requests, defaults to DefaultSessionIdleTimeout
+	IdleTimeout time.Duration
+	// AbsoluteTimeout ends sessions regardless of activity, defaults to DefaultSessionAbsoluteTimeout
+	AbsoluteTimeout time.Duration
+	defaultStore    SessionStore
+	defaultOnce     sync.Once
+}
+
+// SessionData is the session of a single http.Request
+type SessionData struct {
+	record    SessionRecord
+	isNew     bool
+	modified  bool
+	rotate    bool
+	destroyed bool
+	mutex     sync.Mutex
+}
+
+// Session returns the SessionData of an http.Request, or nil outside of a SessionManager
+func Session(r *http.Request) *SessionData {
+	session, _ := r.Context().Value(sessionContextKey).(*SessionData)
+
+	return session
+}
+
+// ID returns the ID of the session, which changes when it is rotated
+func (session *SessionData) ID() string {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	return session.record.ID
+}
+
+// Get returns a value from the session
+func (session *SessionData) Get(key string) string {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	return session.record.Values[key]
+}
+
+// Set stores a value in the session
+func (session *SessionData) Set(key string, value string) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	session.record.Values[key] = value
+	session.modified = true
+}
+
+// Delete removes a value from the session
+func (session *SessionData) Delete(key string) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	delete(session.record.Values, key)
+	session.modified = true
+}
+
+// Rotate gives the session a new ID while keeping its values, call it whenever
+// the privilege level changes, like on login, to prevent session fixation
+func (session *SessionData) Rotate() {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	session.rotate = true
+	session.modified = true
+}
+
+// Destroy removes the session from the SessionStore and clears the cookie
+func (session *SessionData) Destroy() {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	session.destroyed = true
+	session.record.Values = map[string]string{}
+}
+
+// LogIn stores a User in the session for the SessionGuard and rotates the session ID
+func LogIn(r *http.Request, user User) error {
+	session := Session(r)
+	if session == nil {
+		return ErrSessionNotFound
+	}
+
+	session.Set(sessionUserKey, user.GetUsername())
+	session.Rotate()
+
+	return nil
+}
+
+// LogOut removes the User from the session and rotates the session ID
+func LogOut(r *http.Request) {
+	if session := Session(r); session != nil {
+		session.Delete(sessionUserKey)
+		session.Rotate()
+	}
+}
+
+// ServerHTTP satisfies the http.Handler interface
+func (manager *SessionManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if manager.Handler == nil {
+		return
+	}
+
+	session := manager.load(r, time.Now())
+
+	writer := &sessionWriter{
+		ResponseWriter: w,
+		commit: func() {
+			manager.commit(w, r, session, time.Now())
+		},
+	}
+
+	sessionRequest := r.WithContext(context.WithValue(r.Context(), sessionContextKey, session))
+
+	// Only advertise http.Flusher when the wrapped http.ResponseWriter supports it
+	if _, ok := w.(http.Flusher); ok {
+		manager.Handler.ServeHTTP(&flushingSessionWriter{sessionWriter: writer}, sessionRequest)
+	} else {
+		manager.Handler.ServeHTTP(writer, sessionRequest)
+	}
+
+	writer.commitOnce()
+}
+
+func (manager *SessionManager) load(r *http.Request, now time.Time) *SessionData {
+	if cookie, err := r.Cookie(manager.cookieName()); err == nil && cookie.Value != "" {
+		if record, err := manager.store().Load(cookie.Value); err == nil && record != nil {
+			if manager.valid(record, now) {
+				if record.Values == nil {
+					record.Values = map[string]string{}
+				}
+
+				return &SessionData{record: *record}
+			}
+
+			_ = manager.store().Delete(record.ID)
+		}
+	}
+
+	return &SessionData{
+		record: SessionRecord{
+			Values:    map[string]string{},
+			CreatedAt: now,
+			LastSeen:  now,
+		},
+		isNew: true,
+	}
+}
+
+func (manager *SessionManager) valid(record *SessionRecord, now time.Time) bool {
+	if now.Sub(record.LastSeen) > manager.idleTimeout() {
+		return false
+	}
+
+	if now.Sub(record.CreatedAt) > manager.absoluteTimeout() {
+		return false
+	}
+
+	return record.ExpiresAt.IsZero() || now.Before(record.ExpiresAt)
+}
+
+// commit persists the session and sets the cookie before the response is written.
+// Unmodified sessions are only saved once LastSeen is old enough to be worth extending.
+func (manager *SessionManager) commit(w http.ResponseWriter, r *http.Request, session *SessionData, now time.Time) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	store := manager.store()
+
+	if session.destroyed {
+		if !session.isNew {
+			_ = store.Delete(session.record.ID)
+			http.SetCookie(w, manager.cookie(r, "", time.Unix(0, 0), -1))
+		}
+
+		return
+	}
+
+	if session.isNew && !session.modified {
+		return
+	}
+
+	if !session.modified && now.Sub(session.record.LastSeen) < manager.idleTimeout()/sessionTouchDivisor {
+		return
+	}
+
+	if session.isNew || session.rotate {
+		if !session.isNew {
+			_ = store.Delete(session.record.ID)
+		}
+
+		id, err := newSessionID()
+		if err != nil {
+			return
+		}
+
+		session.record.ID = id
+	}
+
+	session.record.LastSeen = now
+	session.record.ExpiresAt = session.record.LastSeen.Add(manager.idleTimeout())
+	if absoluteExpiry := session.record.CreatedAt.Add(manager.absoluteTimeout()); absoluteExpiry.Before(session.record.ExpiresAt) {
+		session.record.ExpiresAt = absoluteExpiry
+	}
+
+	record := session.record
+	record.Values = copySessionValues(session.record.Values)
+
+	cookieValue, err := store.Save(&record)
+	if err != nil {
+		return
+	}
+
+	http.SetCookie(w, manager.cookie(r, cookieValue, session.record.ExpiresAt, 0))
+}
+
+func (manager *SessionManager) cookie(r *http.Request, value string, expires time.Time, maxAge int) *http.Cookie {
+	path := manager.CookiePath
+	if path == "" {
+		path = "/"
+	}
+
+	sameSite := manager.CookieSameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteLaxMode
+	}
+
+	return &http.Cookie{
+		Name:     manager.cookieName(),
+		Value:    value,
+		Path:     path,
+		Domain:   manager.CookieDomain,
+		Expires:  expires,
+		MaxAge:   maxAge,
+		Secure:   manager.CookieSecure || r.TLS != nil,
+		HttpOnly: true,
+		SameSite: sameSite,
+	}
+}
+
+func (manager *SessionManager) cookieName() string {
+	if manager.CookieName == "" {
+		return DefaultSessionCookieName
+	}
+
+	return manager.CookieName
+}
+
+func (manager *SessionManager) idleTimeout() time.Duration {
+	if manager.IdleTimeout <= 0 {
+		return DefaultSessionIdleTimeout
+	}
+
+	return manager.IdleTimeout
+}
+
+func (manager *SessionManager) absoluteTimeout() time.Duration {
+	if manager.AbsoluteTimeout <= 0 {
+		return DefaultSessionAbsoluteTimeout
+	}
+
+	return manager.AbsoluteTimeout
+}
+
+func (manager *SessionManager) store() SessionStore {
+	if manager.Store != nil {
+		return manager.Store
+	}
+
+	manager.defaultOnce.Do(func() {
+		manager.defaultStore = &MemorySessionStore{}
+	})
+
+	return manager.defaultStore
+}
+
+func newSessionID() (string, error) {
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+// sessionWriter commits the session right before the response headers are written
+type sessionWriter struct {
+	http.ResponseWriter
+	commit    func()
+	committed bool
+}
+
+func (writer *sessionWriter) commitOnce() {
+	if writer.committed {
+		return
+	}
+
+	writer.committed = true
+	writer.commit()
+}
+
+func (writer *sessionWriter) WriteHeader(status int) {
+	writer.commitOnce()
+	writer.ResponseWriter.WriteHeader(status)
+}
+
+func (writer *sessionWriter) Write(p []byte) (int, error) {
+	writer.commitOnce()
+
+	return writer.ResponseWriter.Write(p)
+}
+
+func (writer *sessionWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := writer.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	writer.commitOnce()
+
+	return hijacker.Hijack()
+}
+
+type flushingSessionWriter struct {
+	*sessionWriter
+}
+
+func (writer *flushingSessionWriter) Flush() {
+	writer.commitOnce()
+	writer.ResponseWriter.(http.Flusher).Flush()
+}
+
+// SessionGuard is a Guard for the User stored in the session by LogIn
+type SessionGuard struct {
+	Users UserProvider
+}
+
+// Supports satisfies the Guard interface
+func (guard *SessionGuard) Supports(request *http.Request) bool {
+	session := Session(request)
+
+	return session != nil && session.Get(sessionUserKey) != ""
+}
+
+// GetCredentials satisfies the Guard interface
+func (guard *SessionGuard) GetCredentials(request *http.Request) interface{} {
+	return Session(request).Get(sessionUserKey)
+}
+
+// GetUser satisfies the Guard interface
+func (guard *SessionGuard) GetUser(credentials interface{}) (User, error) {
+	username, _ := credentials.(string)
+	if username == "" || guard.Users == nil {
+		return nil, nil
+	}
+
+	return guard.Users.LoadUser(username)
+}
+
+// CheckCredentials satisfies the Guard interface, the session is trusted
+func (guard *SessionGuard) CheckCredentials(user interface{}, credentials interface{}) (bool, error) {
+	return user != nil, nil
+}
+
+// OnAuthenticationFailure satisfies the Guard interface
+func (guard *SessionGuard) OnAuthenticationFailure(w http.ResponseWriter, r *http.Request) {
+	RespondError(w, r, NewError(http.StatusUnauthorized, ""))
+}
+
+// OnAuthenticationSuccess satisfies the Guard interface
+func (guard *SessionGuard) OnAuthenticationSuccess(w http.ResponseWriter, r *http.Request) {}
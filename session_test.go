@@ -0,0 +1,219 @@
+package forge_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func sessionTestRequest(handler http.Handler, path string, cookie *http.Cookie) (*httptest.ResponseRecorder, *http.Cookie) {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	if cookie != nil {
+		request.AddCookie(cookie)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	for _, responseCookie := range recorder.Result().Cookies() {
+		if responseCookie.Name == forge.DefaultSessionCookieName {
+			return recorder, responseCookie
+		}
+	}
+
+	return recorder, nil
+}
+
+func newSessionTestHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := forge.Session(r)
+
+		switch r.URL.Path {
+		case "/set":
+			session.Set("color", "blue")
+		case "/delete":
+			session.Delete("color")
+		case "/login":
+			forge.LogIn(r, &passwordTestUser{Username: "aaron"})
+		case "/logout":
+			forge.LogOut(r)
+		case "/destroy":
+			session.Destroy()
+		}
+
+		w.Write([]byte(session.Get("color")))
+	})
+}
+
+func Test_SessionManager(t *testing.T) {
+	manager := &forge.SessionManager{Handler: newSessionTestHandler()}
+
+	if _, cookie := sessionTestRequest(manager, "/", nil); cookie != nil {
+		t.Fatal("empty sessions should not set a cookie")
+	}
+
+	_, cookie := sessionTestRequest(manager, "/set", nil)
+	if cookie == nil || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("unexpected cookie: %v", cookie)
+	}
+
+	if recorder, _ := sessionTestRequest(manager, "/", cookie); recorder.Body.String() != "blue" {
+		t.Fatalf("value not persisted: %q", recorder.Body.String())
+	}
+
+	_, loginCookie := sessionTestRequest(manager, "/login", cookie)
+	if loginCookie == nil || loginCookie.Value == cookie.Value {
+		t.Fatal("session ID should rotate on login")
+	}
+
+	if recorder, _ := sessionTestRequest(manager, "/", cookie); recorder.Body.String() != "" {
+		t.Fatal("the session ID from before login should be invalid")
+	}
+
+	if recorder, _ := sessionTestRequest(manager, "/", loginCookie); recorder.Body.String() != "blue" {
+		t.Fatal("values should survive rotation")
+	}
+
+	sessionTestRequest(manager, "/delete", loginCookie)
+	if recorder, _ := sessionTestRequest(manager, "/", loginCookie); recorder.Body.String() != "" {
+		t.Fatal("value should be deleted")
+	}
+
+	_, destroyCookie := sessionTestRequest(manager, "/destroy", loginCookie)
+	if destroyCookie == nil || destroyCookie.MaxAge >= 0 {
+		t.Fatalf("destroy should clear the cookie: %v", destroyCookie)
+	}
+}
+
+func Test_SessionManager_Timeouts(t *testing.T) {
+	store := &forge.MemorySessionStore{}
+	manager := &forge.SessionManager{
+		Handler:         newSessionTestHandler(),
+		Store:           store,
+		IdleTimeout:     200 * time.Millisecond,
+		AbsoluteTimeout: 500 * time.Millisecond,
+	}
+
+	_, cookie := sessionTestRequest(manager, "/set", nil)
+
+	// Activity keeps the session alive until the absolute timeout
+	for i := 0; i < 3; i++ {
+		time.Sleep(150 * time.Millisecond)
+		if recorder, _ := sessionTestRequest(manager, "/", cookie); recorder.Body.String() != "blue" {
+			t.Fatalf("session should still be active after %d requests", i)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if recorder, _ := sessionTestRequest(manager, "/", cookie); recorder.Body.String() != "" {
+		t.Fatal("session should have reached the absolute timeout")
+	}
+
+	_, cookie = sessionTestRequest(manager, "/set", nil)
+	time.Sleep(250 * time.Millisecond)
+	if recorder, _ := sessionTestRequest(manager, "/", cookie); recorder.Body.String() != "" {
+		t.Fatal("session should have been idle too long")
+	}
+}
+
+// countingSessionStore counts the Saves of a MemorySessionStore
+type countingSessionStore struct {
+	forge.MemorySessionStore
+	saves int
+}
+
+func (store *countingSessionStore) Save(record *forge.SessionRecord) (string, error) {
+	store.saves++
+
+	return store.MemorySessionStore.Save(record)
+}
+
+func Test_SessionManager_SavesOnlyChanges(t *testing.T) {
+	store := &countingSessionStore{}
+	manager := &forge.SessionManager{
+		Handler:     newSessionTestHandler(),
+		Store:       store,
+		IdleTimeout: time.Second,
+	}
+
+	_, cookie := sessionTestRequest(manager, "/set", nil)
+
+	if recorder, responseCookie := sessionTestRequest(manager, "/", cookie); recorder.Body.String() != "blue" || responseCookie != nil {
+		t.Fatalf("body: %q cookie: %v", recorder.Body.String(), responseCookie)
+	}
+
+	if store.saves != 1 {
+		t.Fatalf("unmodified session saved, saves: %d", store.saves)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if _, responseCookie := sessionTestRequest(manager, "/", cookie); responseCookie == nil || store.saves != 2 {
+		t.Fatalf("idle session should be extended, saves: %d", store.saves)
+	}
+}
+
+func Test_SessionGuard(t *testing.T) {
+	security := &forge.Security{
+		Guards: []forge.Guard{
+			&forge.SessionGuard{Users: newUserTestProvider()},
+		},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("Hello " + forge.CurrentUser(r).GetUsername()))
+		}),
+	}
+
+	router := &forge.Router{}
+	router.Handle("/login", newSessionTestHandler())
+	router.Handle("/logout", newSessionTestHandler())
+	router.Handle("/admin", security)
+
+	manager := &forge.SessionManager{Handler: router}
+
+	if recorder, _ := sessionTestRequest(manager, "/admin", nil); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("status: %d", recorder.Code)
+	}
+
+	_, cookie := sessionTestRequest(manager, "/login", nil)
+
+	if recorder, _ := sessionTestRequest(manager, "/admin", cookie); recorder.Body.String() != "Hello aaron" {
+		t.Fatalf("body: %q", recorder.Body.String())
+	}
+
+	_, cookie = sessionTestRequest(manager, "/logout", cookie)
+
+	if recorder, _ := sessionTestRequest(manager, "/admin", cookie); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("status after logout: %d", recorder.Code)
+	}
+}
+
+func Test_SessionManager_Unflushable(t *testing.T) {
+	manager := &forge.SessionManager{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := forge.NewEventStream(w, r); err != forge.ErrStreamingUnsupported {
+				t.Fatalf("SessionManager should not add http.Flusher, got: %v", err)
+			}
+		}),
+	}
+
+	manager.ServeHTTP(nonFlushingWriter{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func Test_SessionManager_Hijack(t *testing.T) {
+	manager := &forge.SessionManager{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, _, err := w.(http.Hijacker).Hijack(); err != nil {
+				t.Fatalf("hijack: %s", err)
+			}
+		}),
+	}
+
+	writer := &compressTestWriter{ResponseRecorder: httptest.NewRecorder()}
+	manager.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !writer.hijacked {
+		t.Fatal("SessionManager should pass http.Hijacker through")
+	}
+}
@@ -0,0 +1,359 @@
+package forge
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSessionSweepInterval is the minimum time between sweeps of expired sessions
+// by server-side stores
+const DefaultSessionSweepInterval = time.Minute
+
+// maxSessionCookieSize keeps cookies under the 4096 byte limit of browsers
+const maxSessionCookieSize = 4000
+
+var (
+	// ErrSessionKey is returned when a CookieSessionStore has no valid AES key
+	ErrSessionKey = errors.New("session key must be 16, 24 or 32 bytes")
+
+	// ErrSessionTooLarge is returned when a session does not fit in a cookie
+	ErrSessionTooLarge = errors.New("session too large for cookie")
+)
+
+// CookieSessionStore is a SessionStore keeping the whole session in the cookie,
+// encrypted and authenticated with AES-GCM. The first of Keys encrypts, all of them
+// decrypt, so keys can be rotated by prepending a new one. Sessions can not be
+// revoked before they expire.
+type CookieSessionStore struct {
+	Keys [][]byte
+}
+
+// Load satisfies the SessionStore interface
+func (store *CookieSessionStore) Load(cookieValue string) (*SessionRecord, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(cookieValue)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	for _, key := range store.Keys {
+		aead, err := newSessionAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(sealed) < aead.NonceSize() {
+			return nil, ErrSessionNotFound
+		}
+
+		plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+		if err != nil {
+			continue
+		}
+
+		record := &SessionRecord{}
+		if err := json.Unmarshal(plaintext, record); err != nil || sessionExpired(record, time.Now()) {
+			return nil, ErrSessionNotFound
+		}
+
+		return record, nil
+	}
+
+	return nil, ErrSessionNotFound
+}
+
+// Save satisfies the SessionStore interface
+func (store *CookieSessionStore) Save(record *SessionRecord) (string, error) {
+	if len(store.Keys) == 0 {
+		return "", ErrSessionKey
+	}
+
+	aead, err := newSessionAEAD(store.Keys[0])
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	cookieValue := base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil))
+	if len(cookieValue) > maxSessionCookieSize {
+		return "", ErrSessionTooLarge
+	}
+
+	return cookieValue, nil
+}
+
+// Delete satisfies the SessionStore interface, the cookie is cleared by the SessionManager
+func (store *CookieSessionStore) Delete(id string) error {
+	return nil
+}
+
+func newSessionAEAD(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrSessionKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// MemorySessionStore is a SessionStore kept in memory. Expiry is enforced lazily:
+// expired sessions are removed when they are loaded and by a sweep that Save runs
+// at most every SweepInterval, which defaults to DefaultSessionSweepInterval. There
+// is no background goroutine, so a store without Saves keeps its expired sessions.
+type MemorySessionStore struct {
+	SweepInterval time.Duration
+	records       map[string]SessionRecord
+	lastSweep     time.Time
+	mutex         sync.Mutex
+}
+
+// Load satisfies the SessionStore interface
+func (store *MemorySessionStore) Load(cookieValue string) (*SessionRecord, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	record, ok := store.records[cookieValue]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	if sessionExpired(&record, time.Now()) {
+		delete(store.records, cookieValue)
+		return nil, ErrSessionNotFound
+	}
+
+	record.Values = copySessionValues(record.Values)
+
+	return &record, nil
+}
+
+// Save satisfies the SessionStore interface
+func (store *MemorySessionStore) Save(record *SessionRecord) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.records == nil {
+		store.records = make(map[string]SessionRecord)
+	}
+
+	now := time.Now()
+	if now.Sub(store.lastSweep) >= sweepInterval(store.SweepInterval) {
+		store.lastSweep = now
+		for id, stored := range store.records {
+			if sessionExpired(&stored, now) {
+				delete(store.records, id)
+			}
+		}
+	}
+
+	stored := *record
+	stored.Values = copySessionValues(record.Values)
+	store.records[record.ID] = stored
+
+	return record.ID, nil
+}
+
+// Delete satisfies the SessionStore interface
+func (store *MemorySessionStore) Delete(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.records, id)
+
+	return nil
+}
+
+// Len returns the number of stored sessions, including expired ones not yet swept
+func (store *MemorySessionStore) Len() int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return len(store.records)
+}
+
+// FileSessionStore is a SessionStore keeping one JSON file per session in Directory.
+// Like the MemorySessionStore, expired sessions are removed lazily when they are
+// loaded and by a sweep that Save runs at most every SweepInterval.
+type FileSessionStore struct {
+	Directory     string
+	SweepInterval time.Duration
+	lastSweep     time.Time
+	mutex         sync.Mutex
+}
+
+// Load satisfies the SessionStore interface
+func (store *FileSessionStore) Load(cookieValue string) (*SessionRecord, error) {
+	if !validSessionID(cookieValue) {
+		return nil, ErrSessionNotFound
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	fileBytes, err := ioutil.ReadFile(store.path(cookieValue))
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	record := &SessionRecord{}
+	if err := json.Unmarshal(fileBytes, record); err != nil || record.ID != cookieValue {
+		return nil, ErrSessionNotFound
+	}
+
+	if sessionExpired(record, time.Now()) {
+		_ = os.Remove(store.path(cookieValue))
+		return nil, ErrSessionNotFound
+	}
+
+	return record, nil
+}
+
+// Save satisfies the SessionStore interface
+func (store *FileSessionStore) Save(record *SessionRecord) (string, error) {
+	if !validSessionID(record.ID) {
+		return "", ErrSessionNotFound
+	}
+
+	fileBytes, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if err := os.MkdirAll(store.Directory, 0700); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if now.Sub(store.lastSweep) >= sweepInterval(store.SweepInterval) {
+		store.lastSweep = now
+		store.sweep(now)
+	}
+
+	// Write to a temporary file first so readers never see a partial session
+	tempFile, err := ioutil.TempFile(store.Directory, ".session-")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tempFile.Write(fileBytes); err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	if err := os.Rename(tempFile.Name(), store.path(record.ID)); err != nil {
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	return record.ID, nil
+}
+
+// Delete satisfies the SessionStore interface
+func (store *FileSessionStore) Delete(id string) error {
+	if !validSessionID(id) {
+		return nil
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if err := os.Remove(store.path(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (store *FileSessionStore) sweep(now time.Time) {
+	fileInfos, err := ioutil.ReadDir(store.Directory)
+	if err != nil {
+		return
+	}
+
+	for _, fileInfo := range fileInfos {
+		id := strings.TrimSuffix(fileInfo.Name(), ".json")
+		if fileInfo.IsDir() || !validSessionID(id) {
+			continue
+		}
+
+		fileBytes, err := ioutil.ReadFile(store.path(id))
+		if err != nil {
+			continue
+		}
+
+		record := &SessionRecord{}
+		if err := json.Unmarshal(fileBytes, record); err != nil || sessionExpired(record, now) {
+			_ = os.Remove(store.path(id))
+		}
+	}
+}
+
+func (store *FileSessionStore) path(id string) string {
+	return filepath.Join(store.Directory, id+".json")
+}
+
+// validSessionID checks that an ID came from newSessionID and is safe to use as a file name
+func validSessionID(id string) bool {
+	if len(id) != 64 {
+		return false
+	}
+
+	_, err := hex.DecodeString(id)
+
+	return err == nil
+}
+
+func sessionExpired(record *SessionRecord, now time.Time) bool {
+	return !record.ExpiresAt.IsZero() && !now.Before(record.ExpiresAt)
+}
+
+func sweepInterval(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return DefaultSessionSweepInterval
+	}
+
+	return interval
+}
+
+func copySessionValues(values map[string]string) map[string]string {
+	copied := make(map[string]string, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+
+	return copied
+}
@@ -0,0 +1,106 @@
+package forge_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func newSessionTestRecord(expiresAt time.Time) *forge.SessionRecord {
+	return &forge.SessionRecord{
+		ID:        strings.Repeat("ab", 32),
+		Values:    map[string]string{"color": "blue"},
+		CreatedAt: time.Now(),
+		LastSeen:  time.Now(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+func Test_SessionStores(t *testing.T) {
+	stores := map[string]forge.SessionStore{
+		"cookie": &forge.CookieSessionStore{Keys: [][]byte{[]byte("0123456789abcdef")}},
+		"memory": &forge.MemorySessionStore{},
+		"file":   &forge.FileSessionStore{Directory: t.TempDir()},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			cookieValue, err := store.Save(newSessionTestRecord(time.Now().Add(time.Hour)))
+			if err != nil {
+				t.Fatalf("save: %s", err)
+			}
+
+			record, err := store.Load(cookieValue)
+			if err != nil || record.Values["color"] != "blue" {
+				t.Fatalf("load: %v %v", record, err)
+			}
+
+			if _, err := store.Load("../../etc/passwd"); err != forge.ErrSessionNotFound {
+				t.Fatalf("unknown session: %v", err)
+			}
+
+			expiredCookieValue, _ := store.Save(newSessionTestRecord(time.Now().Add(-time.Second)))
+			if _, err := store.Load(expiredCookieValue); err != forge.ErrSessionNotFound {
+				t.Fatalf("expired session: %v", err)
+			}
+		})
+	}
+}
+
+func Test_CookieSessionStore(t *testing.T) {
+	oldKey := []byte("0123456789abcdef")
+	newKey := []byte("fedcba9876543210fedcba9876543210")
+
+	oldStore := &forge.CookieSessionStore{Keys: [][]byte{oldKey}}
+	rotatedStore := &forge.CookieSessionStore{Keys: [][]byte{newKey, oldKey}}
+
+	cookieValue, _ := oldStore.Save(newSessionTestRecord(time.Time{}))
+	if strings.Contains(cookieValue, "blue") {
+		t.Fatal("cookie should be encrypted")
+	}
+
+	if _, err := rotatedStore.Load(cookieValue); err != nil {
+		t.Fatalf("rotated keys should decrypt old cookies: %s", err)
+	}
+
+	tampered := []byte(cookieValue)
+	tampered[len(tampered)/2] ^= 1
+	if _, err := oldStore.Load(string(tampered)); err != forge.ErrSessionNotFound {
+		t.Fatalf("tampered cookie: %v", err)
+	}
+
+	if _, err := (&forge.CookieSessionStore{Keys: [][]byte{[]byte("short")}}).Save(newSessionTestRecord(time.Time{})); err != forge.ErrSessionKey {
+		t.Fatalf("short key: %v", err)
+	}
+
+	record := newSessionTestRecord(time.Time{})
+	record.Values["large"] = strings.Repeat("x", 5000)
+	if _, err := oldStore.Save(record); err != forge.ErrSessionTooLarge {
+		t.Fatalf("large session: %v", err)
+	}
+}
+
+func Test_SessionStores_Sweep(t *testing.T) {
+	memoryStore := &forge.MemorySessionStore{SweepInterval: time.Nanosecond}
+	fileStore := &forge.FileSessionStore{Directory: t.TempDir(), SweepInterval: time.Nanosecond}
+
+	for _, store := range []forge.SessionStore{memoryStore, fileStore} {
+		store.Save(newSessionTestRecord(time.Now().Add(-time.Second)))
+
+		fresh := newSessionTestRecord(time.Now().Add(time.Hour))
+		fresh.ID = strings.Repeat("cd", 32)
+		store.Save(fresh)
+	}
+
+	if memoryStore.Len() != 1 {
+		t.Fatalf("memory sessions: %d", memoryStore.Len())
+	}
+
+	files, _ := ioutil.ReadDir(fileStore.Directory)
+	if len(files) != 1 {
+		t.Fatalf("file sessions: %d", len(files))
+	}
+}
@@ -1,21 +1,131 @@
 package forge
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 )
 
-// Static servers static files without directory listings
+// Cache Control Constants
+const (
+	CacheControlNoCache   = "no-cache"
+	CacheControlImmutable = "public, max-age=31536000, immutable"
+)
+
+var precompressedExtensions = []struct {
+	encoding  string
+	extension string
+}{
+	{encoding: EncodingBrotli, extension: ".br"},
+	{encoding: EncodingGzip, extension: ".gz"},
+}
+
+// Static serves static files from a FileSystem, with optional directory listings
 type Static struct {
 	FileSystem      http.FileSystem
 	NotFoundHandler http.Handler
-	fileServer      http.Handler
+	ErrorPages      *ErrorPages
+	// Precompressed serves "name.br" or "name.gz" siblings to clients that accept them
+	Precompressed bool
+	// CachePolicy decides the Cache-Control header of each file, defaults to no-cache
+	CachePolicy CachePolicy
+	// Fallback is served for missing extension-less paths that accept HTML, like "/index.html"
+	// for a single-page application
+	Fallback string
+	// FallbackExcludePrefixes are path prefixes that never serve the Fallback, like "/api/"
+	FallbackExcludePrefixes []string
+	// DirectoryListing renders a Listing for directories without an index.html
+	DirectoryListing bool
+	// ListingTemplate renders HTML listings, defaults to DefaultListingTemplate
+	ListingTemplate *template.Template
+	// ListingShowHidden includes the AllowedHiddenFiles in listings, other dotfiles are never listed
+	ListingShowHidden bool
+	// AllowedHiddenFiles are dotfile or dot-directory names that may be served, like ".well-known"
+	AllowedHiddenFiles []string
+	// BlockSymlinkEscape refuses symlinks that resolve outside of the root of an http.Dir
+	BlockSymlinkEscape bool
+	// CleanURLs serves "/about" from "about.html" and redirects "/about.html" to "/about"
+	CleanURLs  bool
+	fileServer http.Handler
+	fileSystem http.FileSystem
+	initOnce   sync.Once
+	etags      sync.Map
+}
+
+// CachePolicy decides the Cache-Control header for a file served by Static
+type CachePolicy interface {
+	CacheControl(name string) string
+}
+
+// CachePolicyFunc is a function that satisfies the CachePolicy interface
+type CachePolicyFunc func(name string) string
+
+// CacheControl satisfies the CachePolicy interface
+func (policyFunc CachePolicyFunc) CacheControl(name string) string {
+	return policyFunc(name)
+}
+
+// CacheRule applies a Cache-Control value to files matching Pattern. A Pattern starting
+// with "." matches a file extension, a Pattern containing "/" is matched against the full
+// path with path.Match and any other Pattern is matched against the base name.
+type CacheRule struct {
+	Pattern      string
+	CacheControl string
+}
+
+// CacheRules is a CachePolicy using the first matching CacheRule, falling back to no-cache
+type CacheRules []CacheRule
+
+// CacheControl satisfies the CachePolicy interface
+func (rules CacheRules) CacheControl(name string) string {
+	for _, rule := range rules {
+		if rule.matches(name) {
+			return rule.CacheControl
+		}
+	}
+
+	return CacheControlNoCache
+}
+
+func (rule CacheRule) matches(name string) bool {
+	if strings.HasPrefix(rule.Pattern, ".") && !strings.ContainsAny(rule.Pattern, "*?[") {
+		return path.Ext(name) == rule.Pattern
+	}
+
+	if strings.Contains(rule.Pattern, "/") {
+		matched, _ := path.Match(rule.Pattern, name)
+		return matched
+	}
+
+	matched, _ := path.Match(rule.Pattern, path.Base(name))
+
+	return matched
 }
 
 // ServerHTTP satisfies the http.Handler interface
 func (static *Static) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if static.fileServer == nil {
-		static.fileServer = http.FileServer(static.FileSystem)
+	if !isSafePath(r) {
+		static.ErrorPages.Serve(w, r, http.StatusBadRequest)
+		return
+	}
+
+	if !static.allowed(r.URL.Path) {
+		static.notFound(w, r)
+		return
+	}
+
+	if static.DirectoryListing && static.serveListing(w, r) {
+		return
 	}
 
 	requestedFileName := r.URL.Path
@@ -25,13 +135,306 @@ func (static *Static) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		requestedFileName += "index.html"
 	}
 
-	if !static.fileExists(requestedFileName) {
+	if static.CleanURLs {
+		if isCleanableHTMLPath(r.URL.Path) && static.fileExists(requestedFileName) {
+			localRedirect(w, r, strings.TrimSuffix(path.Base(r.URL.Path), ".html"), http.StatusTemporaryRedirect)
+			return
+		}
+
+		if !requestingDirectory && !static.fileExists(requestedFileName) && static.fileExists(requestedFileName+".html") {
+			requestedFileName += ".html"
+			r = requestWithPath(r, requestedFileName)
+		}
+	}
+
+	if !static.fileExists(requestedFileName) || !static.allowed(requestedFileName) {
+		if static.serveFallback(w, r) {
+			return
+		}
+
 		static.notFound(w, r)
 		return
 	}
 
-	w.Header().Add(HeaderCacheControl, "no-cache")
-	static.fileServer.ServeHTTP(w, r)
+	if cacheControl := static.cacheControl(requestedFileName); cacheControl != "" {
+		w.Header().Set(HeaderCacheControl, cacheControl)
+	}
+
+	if static.Precompressed {
+		w.Header().Add(HeaderVary, HeaderAcceptEncoding)
+
+		if static.servePrecompressed(w, r, requestedFileName) {
+			return
+		}
+	}
+
+	if file, fileInfo, ok := static.openRegularFile(path.Clean("/" + requestedFileName)); ok {
+		static.setContentETag(w, requestedFileName, file, fileInfo)
+		file.Close()
+	}
+
+	static.server().ServeHTTP(w, r)
+}
+
+// serveFallback serves the Fallback file when the http.Request looks like a page navigation
+func (static *Static) serveFallback(w http.ResponseWriter, r *http.Request) bool {
+	if static.Fallback == "" {
+		return false
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if path.Ext(r.URL.Path) != "" || !acceptsMediaType(r, "text/html") {
+		return false
+	}
+
+	for _, prefix := range static.FallbackExcludePrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return false
+		}
+	}
+
+	name := path.Clean("/" + static.Fallback)
+
+	file, fileInfo, ok := static.openRegularFile(name)
+	if !ok {
+		return false
+	}
+	defer file.Close()
+
+	if cacheControl := static.cacheControl(name); cacheControl != "" {
+		w.Header().Set(HeaderCacheControl, cacheControl)
+	}
+
+	static.setContentETag(w, name, file, fileInfo)
+	http.ServeContent(w, r, name, fileInfo.ModTime(), file)
+
+	return true
+}
+
+func (static *Static) cacheControl(name string) string {
+	if static.CachePolicy == nil {
+		return CacheControlNoCache
+	}
+
+	return static.CachePolicy.CacheControl(path.Clean("/" + name))
+}
+
+// setContentETag sets a strong ETag from the content hash of files without a modification
+// time, like the ones in an embed.FS, so conditional requests still work
+func (static *Static) setContentETag(w http.ResponseWriter, name string, file http.File, fileInfo os.FileInfo) {
+	if !fileInfo.ModTime().IsZero() || w.Header().Get(HeaderETag) != "" {
+		return
+	}
+
+	cacheKey := path.Clean("/"+name) + ":" + strconv.FormatInt(fileInfo.Size(), 10)
+	if etag, ok := static.etags.Load(cacheKey); ok {
+		w.Header().Set(HeaderETag, etag.(string))
+		return
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return
+	}
+
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	static.etags.Store(cacheKey, etag)
+	w.Header().Set(HeaderETag, etag)
+}
+
+// servePrecompressed serves the preferred precompressed sibling of a file if one exists
+func (static *Static) servePrecompressed(w http.ResponseWriter, r *http.Request, name string) bool {
+	name = path.Clean("/" + name)
+
+	extensions := map[string]string{}
+	offers := []string{}
+	for _, precompressed := range precompressedExtensions {
+		extensions[precompressed.encoding] = precompressed.extension
+		offers = append(offers, precompressed.encoding)
+	}
+
+	for len(offers) > 0 {
+		encoding := negotiateEncoding(r, offers...)
+		if encoding == "" {
+			return false
+		}
+
+		offers = removeString(offers, encoding)
+
+		file, fileInfo, ok := static.openRegularFile(name + extensions[encoding])
+		if !ok {
+			continue
+		}
+		defer file.Close()
+
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		w.Header().Set(HeaderContentType, contentType)
+		w.Header().Set(HeaderContentEncoding, encoding)
+		static.setContentETag(w, name+extensions[encoding], file, fileInfo)
+		http.ServeContent(w, r, name, fileInfo.ModTime(), file)
+
+		return true
+	}
+
+	return false
+}
+
+func (static *Static) openRegularFile(name string) (http.File, os.FileInfo, bool) {
+	file, err := static.files().Open(name)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil || fileInfo.IsDir() {
+		file.Close()
+		return nil, nil, false
+	}
+
+	return file, fileInfo, true
+}
+
+// allowed checks a path against the hidden file rules
+func (static *Static) allowed(name string) bool {
+	name = path.Clean("/" + name)
+
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") && !static.hiddenFileAllowed(segment) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// files returns the FileSystem every file is opened from. With BlockSymlinkEscape an
+// http.Dir is wrapped so the check applies to whatever is actually opened, including
+// precompressed siblings and index.html files.
+func (static *Static) files() http.FileSystem {
+	static.init()
+
+	return static.fileSystem
+}
+
+// server returns the http.FileServer for the FileSystem from files
+func (static *Static) server() http.Handler {
+	static.init()
+
+	return static.fileServer
+}
+
+// init builds the FileSystem and http.FileServer once, on the first http.Request
+func (static *Static) init() {
+	static.initOnce.Do(func() {
+		static.fileSystem = static.FileSystem
+
+		if directory, ok := static.FileSystem.(http.Dir); ok && static.BlockSymlinkEscape {
+			static.fileSystem = newSymlinkGuardedDir(directory)
+		}
+
+		static.fileServer = http.FileServer(static.fileSystem)
+	})
+}
+
+func (static *Static) hiddenFileAllowed(name string) bool {
+	for _, allowedName := range static.AllowedHiddenFiles {
+		if name == allowedName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// symlinkGuardedDir is an http.Dir refusing to open files that resolve outside of its root
+type symlinkGuardedDir struct {
+	directory    http.Dir
+	resolvedRoot string
+	rootErr      error
+}
+
+func newSymlinkGuardedDir(directory http.Dir) *symlinkGuardedDir {
+	root := string(directory)
+	if root == "" {
+		root = "."
+	}
+
+	guardedDir := &symlinkGuardedDir{directory: directory}
+
+	// The root is resolved once, a root that can not be resolved refuses every file
+	guardedDir.resolvedRoot, guardedDir.rootErr = filepath.EvalSymlinks(root)
+	if guardedDir.rootErr == nil {
+		guardedDir.resolvedRoot, guardedDir.rootErr = filepath.Abs(guardedDir.resolvedRoot)
+	}
+
+	return guardedDir
+}
+
+// Open satisfies the http.FileSystem interface
+func (guardedDir *symlinkGuardedDir) Open(name string) (http.File, error) {
+	if guardedDir.rootErr != nil {
+		return nil, os.ErrPermission
+	}
+
+	if escapesRoot(string(guardedDir.directory), guardedDir.resolvedRoot, path.Clean("/"+name)) {
+		return nil, os.ErrNotExist
+	}
+
+	return guardedDir.directory.Open(name)
+}
+
+// escapesRoot checks if a path inside root resolves to somewhere outside of resolvedRoot
+func escapesRoot(root string, resolvedRoot string, name string) bool {
+	if root == "" {
+		root = "."
+	}
+
+	resolvedPath, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		// Missing files are left to the not found handling
+		return false
+	}
+
+	resolvedPath, err = filepath.Abs(resolvedPath)
+	if err != nil {
+		return true
+	}
+
+	relativePath, err := filepath.Rel(resolvedRoot, resolvedPath)
+	if err != nil {
+		return true
+	}
+
+	return relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
+
+// isSafePath rejects traversal attempts, including encoded ones, before touching the FileSystem
+func isSafePath(r *http.Request) bool {
+	if strings.ContainsAny(r.URL.Path, "\\\x00") {
+		return false
+	}
+
+	for _, segment := range strings.Split(r.URL.Path, "/") {
+		if segment == ".." {
+			return false
+		}
+	}
+
+	lowerRawPath := strings.ToLower(r.URL.EscapedPath())
+	for _, encoded := range []string{"%2e%2e", "%2f", "%5c", "%00"} {
+		if strings.Contains(lowerRawPath, encoded) {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (static *Static) notFound(w http.ResponseWriter, r *http.Request) {
@@ -40,11 +443,16 @@ func (static *Static) notFound(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if static.ErrorPages != nil {
+		static.ErrorPages.Serve(w, r, http.StatusNotFound)
+		return
+	}
+
 	notFoundHander(w, r)
 }
 
 func (static *Static) fileExists(path string) bool {
-	file, err := static.FileSystem.Open(path)
+	file, err := static.files().Open(path)
 	if err != nil {
 		return false
 	}
@@ -59,3 +467,40 @@ func (static *Static) fileExists(path string) bool {
 
 	return true
 }
+
+func removeString(values []string, value string) []string {
+	result := []string{}
+	for _, existing := range values {
+		if existing != value {
+			result = append(result, existing)
+		}
+	}
+
+	return result
+}
+
+func isCleanableHTMLPath(urlPath string) bool {
+	return strings.HasSuffix(urlPath, ".html") && path.Base(urlPath) != "index.html" && path.Base(urlPath) != ".html"
+}
+
+// requestWithPath copies an http.Request with a different URL path
+func requestWithPath(r *http.Request, urlPath string) *http.Request {
+	request := new(http.Request)
+	*request = *r
+	request.URL = new(url.URL)
+	*request.URL = *r.URL
+	request.URL.Path = urlPath
+	request.URL.RawPath = ""
+
+	return request
+}
+
+// localRedirect redirects with a relative Location so it works under a stripped prefix
+func localRedirect(w http.ResponseWriter, r *http.Request, target string, statusCode int) {
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	w.Header().Set("Location", target)
+	w.WriteHeader(statusCode)
+}
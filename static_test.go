@@ -2,7 +2,11 @@ package forge_test
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/fuzzingbits/forge"
 )
@@ -85,3 +89,328 @@ func Test_Static_CustomNotFound(t *testing.T) {
 		TargetBody:       customNotFoundResponse,
 	})
 }
+
+func Test_Static_Precompressed(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Path           string
+		AcceptEncoding string
+		Encoding       string
+		ContentType    string
+	}{
+		{Name: "brotli", Path: "/precompressed/app.js", AcceptEncoding: "gzip, br", Encoding: forge.EncodingBrotli, ContentType: "javascript"},
+		{Name: "gzip", Path: "/precompressed/app.js", AcceptEncoding: "gzip", Encoding: forge.EncodingGzip, ContentType: "javascript"},
+		{Name: "missing brotli", Path: "/precompressed/style.css", AcceptEncoding: "br, gzip", Encoding: forge.EncodingGzip, ContentType: "text/css"},
+		{Name: "identity", Path: "/precompressed/app.js", AcceptEncoding: "identity", Encoding: "", ContentType: "javascript"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			static := &forge.Static{
+				FileSystem:    http.Dir("./test_files"),
+				Precompressed: true,
+			}
+
+			request, _ := http.NewRequest(http.MethodGet, testCase.Path, nil)
+			request.Header.Set(forge.HeaderAcceptEncoding, testCase.AcceptEncoding)
+
+			handlerTest(t, handlerTestCase{
+				Handler:          static,
+				Request:          request,
+				TargetStatusCode: http.StatusOK,
+				CustomResponseChecker: func(t *testing.T, response *http.Response) {
+					if response.Header.Get(forge.HeaderContentEncoding) != testCase.Encoding {
+						t.Fatalf("content encoding: %s expected: %s", response.Header.Get(forge.HeaderContentEncoding), testCase.Encoding)
+					}
+
+					if !strings.Contains(response.Header.Get(forge.HeaderContentType), testCase.ContentType) {
+						t.Fatalf("content type: %s expected: %s", response.Header.Get(forge.HeaderContentType), testCase.ContentType)
+					}
+
+					if response.Header.Get(forge.HeaderVary) != forge.HeaderAcceptEncoding {
+						t.Fatalf("vary: %s", response.Header.Get(forge.HeaderVary))
+					}
+				},
+			})
+		})
+	}
+}
+
+func Test_Static_CachePolicy(t *testing.T) {
+	static := &forge.Static{
+		FileSystem: http.FS(fstest.MapFS{
+			"index.html":  {Data: []byte("<h1>Index</h1>")},
+			"app.3f9a.js": {Data: []byte("console.log('app');")},
+			"logo.png":    {Data: []byte("png")},
+		}),
+		CachePolicy: forge.CacheRules{
+			{Pattern: "*.*.js", CacheControl: forge.CacheControlImmutable},
+			{Pattern: ".png", CacheControl: "public, max-age=3600"},
+		},
+	}
+
+	testCases := map[string]string{
+		"/":            forge.CacheControlNoCache,
+		"/app.3f9a.js": forge.CacheControlImmutable,
+		"/logo.png":    "public, max-age=3600",
+	}
+
+	for requestPath, cacheControl := range testCases {
+		request, _ := http.NewRequest(http.MethodGet, requestPath, nil)
+
+		handlerTest(t, handlerTestCase{
+			Handler:          static,
+			Request:          request,
+			TargetStatusCode: http.StatusOK,
+			CustomResponseChecker: func(t *testing.T, response *http.Response) {
+				if response.Header.Get(forge.HeaderCacheControl) != cacheControl {
+					t.Fatalf("%s cache control: %s expected: %s", requestPath, response.Header.Get(forge.HeaderCacheControl), cacheControl)
+				}
+			},
+		})
+	}
+}
+
+func Test_Static_ContentETag(t *testing.T) {
+	static := &forge.Static{
+		FileSystem: http.FS(fstest.MapFS{
+			"app.js": {Data: []byte("console.log('app');")},
+		}),
+		CachePolicy: forge.CachePolicyFunc(func(name string) string {
+			return ""
+		}),
+	}
+
+	etag := ""
+
+	request, _ := http.NewRequest(http.MethodGet, "/app.js", nil)
+	handlerTest(t, handlerTestCase{
+		Handler:          static,
+		Request:          request,
+		TargetStatusCode: http.StatusOK,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			etag = response.Header.Get(forge.HeaderETag)
+			if !strings.HasPrefix(etag, `"`) {
+				t.Fatalf("expected strong etag, got: %s", etag)
+			}
+
+			if response.Header.Get(forge.HeaderCacheControl) != "" {
+				t.Fatalf("unexpected cache control: %s", response.Header.Get(forge.HeaderCacheControl))
+			}
+		},
+	})
+
+	conditionalRequest, _ := http.NewRequest(http.MethodGet, "/app.js", nil)
+	conditionalRequest.Header.Set("If-None-Match", etag)
+	handlerTest(t, handlerTestCase{
+		Handler:               static,
+		Request:               conditionalRequest,
+		TargetStatusCode:      http.StatusNotModified,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusNotModified, ""),
+	})
+}
+
+func Test_Static_Fallback(t *testing.T) {
+	static := &forge.Static{
+		FileSystem: http.FS(fstest.MapFS{
+			"index.html": {Data: []byte("<div id=\"app\"></div>")},
+			"app.js":     {Data: []byte("render();")},
+		}),
+		Fallback:                "/index.html",
+		FallbackExcludePrefixes: []string{"/api/"},
+	}
+
+	testCases := []struct {
+		Name       string
+		Path       string
+		Accept     string
+		StatusCode int
+		Body       string
+	}{
+		{Name: "page", Path: "/settings/profile", Accept: "text/html,application/xhtml+xml,*/*;q=0.8", StatusCode: http.StatusOK, Body: "<div id=\"app\"></div>"},
+		{Name: "existing asset", Path: "/app.js", Accept: "*/*", StatusCode: http.StatusOK, Body: "render();"},
+		{Name: "missing asset", Path: "/missing.js", Accept: "text/html", StatusCode: http.StatusNotFound, Body: forge.ResponseTextNotFound},
+		{Name: "not html", Path: "/settings/profile", Accept: "application/json", StatusCode: http.StatusNotFound, Body: `{"status":false,"message":"Not Found","data":{}}` + "\n"},
+		{Name: "excluded prefix", Path: "/api/users", Accept: "text/html", StatusCode: http.StatusNotFound, Body: forge.ResponseTextNotFound},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, testCase.Path, nil)
+			request.Header.Set(forge.HeaderAccept, testCase.Accept)
+
+			handlerTest(t, handlerTestCase{
+				Handler:               static,
+				Request:               request,
+				TargetStatusCode:      testCase.StatusCode,
+				CustomResponseChecker: statusAndBodyChecker(testCase.StatusCode, testCase.Body),
+			})
+		})
+	}
+}
+
+func Test_Static_HiddenFiles(t *testing.T) {
+	static := &forge.Static{
+		FileSystem:         http.Dir("./test_files"),
+		AllowedHiddenFiles: []string{".well-known"},
+	}
+
+	testCases := []struct {
+		Path       string
+		StatusCode int
+		Body       string
+	}{
+		{Path: "/dotenv_tests/basic/.env", StatusCode: http.StatusNotFound, Body: forge.ResponseTextNotFound},
+		{Path: "/listing/.hidden", StatusCode: http.StatusNotFound, Body: forge.ResponseTextNotFound},
+		{Path: "/.well-known/security.txt", StatusCode: http.StatusOK, Body: "Contact: security@example.com\n"},
+	}
+
+	for _, testCase := range testCases {
+		request, _ := http.NewRequest(http.MethodGet, testCase.Path, nil)
+
+		handlerTest(t, handlerTestCase{
+			Handler:               static,
+			Request:               request,
+			TargetStatusCode:      testCase.StatusCode,
+			CustomResponseChecker: statusAndBodyChecker(testCase.StatusCode, testCase.Body),
+		})
+	}
+}
+
+func Test_Static_Traversal(t *testing.T) {
+	static := &forge.Static{
+		FileSystem: http.Dir("./test_files"),
+	}
+
+	for _, rawPath := range []string{
+		"/%2e%2e/go.mod",
+		"/..%2fgo.mod",
+		"/listing%5c..%5c..%5cgo.mod",
+		"/success.txt%00.html",
+	} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.URL, _ = url.Parse(rawPath)
+
+		static.ServeHTTP(recorder, request)
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("%s status code: %d expected: %d", rawPath, recorder.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func Test_Static_SymlinkEscape(t *testing.T) {
+	testCases := []struct {
+		Static     *forge.Static
+		Path       string
+		StatusCode int
+	}{
+		{Static: &forge.Static{FileSystem: http.Dir("./test_files")}, Path: "/symlink_escape.txt", StatusCode: http.StatusOK},
+		{Static: &forge.Static{FileSystem: http.Dir("./test_files"), BlockSymlinkEscape: true}, Path: "/symlink_escape.txt", StatusCode: http.StatusNotFound},
+		{Static: &forge.Static{FileSystem: http.Dir("./test_files"), BlockSymlinkEscape: true}, Path: "/symlink_inside.txt", StatusCode: http.StatusOK},
+		{Static: &forge.Static{FileSystem: http.Dir("./test_files")}, Path: "/symlink_escape/directory/", StatusCode: http.StatusOK},
+		{Static: &forge.Static{FileSystem: http.Dir("./test_files"), BlockSymlinkEscape: true}, Path: "/symlink_escape/directory/", StatusCode: http.StatusNotFound},
+	}
+
+	for _, testCase := range testCases {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, testCase.Path, nil)
+
+		testCase.Static.ServeHTTP(recorder, request)
+
+		if recorder.Code != testCase.StatusCode {
+			t.Fatalf("%s status code: %d expected: %d", testCase.Path, recorder.Code, testCase.StatusCode)
+		}
+	}
+}
+
+func Test_Static_SymlinkEscapePrecompressed(t *testing.T) {
+	static := &forge.Static{
+		FileSystem:         http.Dir("./test_files"),
+		Precompressed:      true,
+		BlockSymlinkEscape: true,
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/symlink_escape/app.js", nil)
+	request.Header.Set(forge.HeaderAcceptEncoding, forge.EncodingGzip)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          static,
+		Request:          request,
+		TargetStatusCode: http.StatusOK,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			if response.Header.Get(forge.HeaderContentEncoding) != "" {
+				t.Fatalf("escaping precompressed file served with encoding: %s", response.Header.Get(forge.HeaderContentEncoding))
+			}
+
+			statusAndBodyChecker(http.StatusOK, "console.log(\"inside\")\n")(t, response)
+		},
+	})
+}
+
+func Test_Static_CleanURLs(t *testing.T) {
+	static := &forge.Static{
+		FileSystem: http.Dir("./test_files"),
+		CleanURLs:  true,
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/clean_urls/about", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:               static,
+		Request:               request,
+		TargetStatusCode:      http.StatusOK,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusOK, "About\n"),
+	})
+}
+
+func Test_Static_CleanURLsRedirect(t *testing.T) {
+	static := &forge.Static{
+		FileSystem: http.Dir("./test_files"),
+		CleanURLs:  true,
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/clean_urls/about.html?lang=en", nil)
+
+	static.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("status code: %d expected: %d", recorder.Code, http.StatusTemporaryRedirect)
+	}
+
+	if recorder.Header().Get("Location") != "about?lang=en" {
+		t.Fatalf("location: %s", recorder.Header().Get("Location"))
+	}
+
+	request, _ = http.NewRequest(http.MethodGet, "/clean_urls/about.html", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          static,
+		Request:          request,
+		TargetStatusCode: http.StatusOK,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			if response.Request.URL.Path != "/clean_urls/about" {
+				t.Fatalf("expected redirect to /clean_urls/about, got: %s", response.Request.URL.Path)
+			}
+
+			statusAndBodyChecker(http.StatusOK, "About\n")(t, response)
+		},
+	})
+}
+
+func Test_Static_CleanURLsDisabled(t *testing.T) {
+	static := &forge.Static{
+		FileSystem: http.Dir("./test_files"),
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/clean_urls/about", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:               static,
+		Request:               request,
+		TargetStatusCode:      http.StatusNotFound,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusNotFound, forge.ResponseTextNotFound),
+	})
+}
@@ -0,0 +1,234 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrStreamingUnsupported is returned when the http.ResponseWriter can not be flushed
+var ErrStreamingUnsupported = errors.New("response writer does not support flushing")
+
+// Event is a single Server-Sent Event
+type Event struct {
+	ID    string
+	Name  string
+	Data  string
+	Retry time.Duration
+}
+
+// EventStream writes Server-Sent Events to a client until it disconnects
+type EventStream struct {
+	writer        http.ResponseWriter
+	flusher       http.Flusher
+	ctx           context.Context
+	lastEventID   string
+	mutex         sync.Mutex
+	heartbeatStop chan struct{}
+	heartbeatDone chan struct{}
+}
+
+// NewEventStream starts a text/event-stream response
+func NewEventStream(w http.ResponseWriter, r *http.Request) (*EventStream, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrStreamingUnsupported
+	}
+
+	w.Header().Set(HeaderContentType, "text/event-stream")
+	w.Header().Set(HeaderCacheControl, "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &EventStream{
+		writer:      w,
+		flusher:     flusher,
+		ctx:         r.Context(),
+		lastEventID: r.Header.Get("Last-Event-ID"),
+	}, nil
+}
+
+// Done is closed when the client disconnects
+func (stream *EventStream) Done() <-chan struct{} {
+	return stream.ctx.Done()
+}
+
+// LastEventID is the ID of the last event the client received before reconnecting
+func (stream *EventStream) LastEventID() string {
+	return stream.lastEventID
+}
+
+// Send writes an Event and flushes it to the client
+func (stream *EventStream) Send(event Event) error {
+	buffer := &bytes.Buffer{}
+
+	if event.ID != "" {
+		fmt.Fprintf(buffer, "id: %s\n", stripNewlines(event.ID))
+	}
+
+	if event.Name != "" {
+		fmt.Fprintf(buffer, "event: %s\n", stripNewlines(event.Name))
+	}
+
+	if event.Retry > 0 {
+		fmt.Fprintf(buffer, "retry: %d\n", event.Retry.Milliseconds())
+	}
+
+	// A lone "\r" also ends a line in SSE, so every line ending becomes its own data field
+	data := strings.ReplaceAll(strings.ReplaceAll(event.Data, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(buffer, "data: %s\n", line)
+	}
+
+	buffer.WriteString("\n")
+
+	return stream.write(buffer.Bytes())
+}
+
+// SendJSON writes an Event with v encoded as JSON for its data
+func (stream *EventStream) SendJSON(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return stream.Send(Event{
+		Name: name,
+		Data: string(data),
+	})
+}
+
+// Comment writes a comment line that clients ignore, useful to keep connections open
+func (stream *EventStream) Comment(text string) error {
+	return stream.write([]byte(": " + stripNewlines(text) + "\n\n"))
+}
+
+// StartHeartbeat writes a comment every interval until the stream is closed or the client disconnects,
+// an interval that is not positive is ignored
+func (stream *EventStream) StartHeartbeat(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	if stream.heartbeatStop != nil {
+		return
+	}
+
+	stream.heartbeatStop = make(chan struct{})
+	stream.heartbeatDone = make(chan struct{})
+
+	go func(stop <-chan struct{}, done chan<- struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-stream.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := stream.Comment("heartbeat"); err != nil {
+					return
+				}
+			}
+		}
+	}(stream.heartbeatStop, stream.heartbeatDone)
+}
+
+// Close stops the heartbeat, it must be called before the handler returns
+func (stream *EventStream) Close() {
+	stream.mutex.Lock()
+	stop := stream.heartbeatStop
+	done := stream.heartbeatDone
+	stream.heartbeatStop = nil
+	stream.mutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+func (stream *EventStream) write(p []byte) error {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	if err := stream.ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, err := stream.writer.Write(p); err != nil {
+		return err
+	}
+
+	stream.flusher.Flush()
+
+	return nil
+}
+
+// NDJSONStream writes newline delimited JSON values to a client, flushing after each one
+type NDJSONStream struct {
+	flusher http.Flusher
+	encoder *json.Encoder
+	ctx     context.Context
+	mutex   sync.Mutex
+}
+
+// NewNDJSONStream starts an application/x-ndjson response
+func NewNDJSONStream(w http.ResponseWriter, r *http.Request) (*NDJSONStream, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrStreamingUnsupported
+	}
+
+	w.Header().Set(HeaderContentType, "application/x-ndjson")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &NDJSONStream{
+		flusher: flusher,
+		encoder: json.NewEncoder(w),
+		ctx:     r.Context(),
+	}, nil
+}
+
+// Done is closed when the client disconnects
+func (stream *NDJSONStream) Done() <-chan struct{} {
+	return stream.ctx.Done()
+}
+
+// Encode writes v as a single line of JSON and flushes it to the client
+func (stream *NDJSONStream) Encode(v interface{}) error {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	if err := stream.ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := stream.encoder.Encode(v); err != nil {
+		return err
+	}
+
+	stream.flusher.Flush()
+
+	return nil
+}
+
+func stripNewlines(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
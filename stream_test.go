@@ -0,0 +1,160 @@
+package forge_test
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fuzzingbits/forge"
+)
+
+type nonFlushingWriter struct {
+	http.ResponseWriter
+}
+
+func Test_EventStream_Success(t *testing.T) {
+	logger := &forge.Logger{
+		Log: log.New(ioutil.Discard, "", 0),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			stream, err := forge.NewEventStream(w, r)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer stream.Close()
+
+			stream.Send(forge.Event{ID: "1", Name: "update", Data: "line one\nline two", Retry: time.Second})
+			stream.SendJSON("count", map[string]int{"count": 2})
+		}),
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          logger,
+		Request:          request,
+		TargetStatusCode: http.StatusOK,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			if response.Header.Get(forge.HeaderContentType) != "text/event-stream" {
+				t.Fatalf("content type: %s", response.Header.Get(forge.HeaderContentType))
+			}
+
+			statusAndBodyChecker(
+				http.StatusOK,
+				"id: 1\nevent: update\nretry: 1000\ndata: line one\ndata: line two\n\nevent: count\ndata: {\"count\":2}\n\n",
+			)(t, response)
+		},
+	})
+}
+
+func Test_EventStream_LineEndings(t *testing.T) {
+	recorder := httptest.NewRecorder()
+
+	stream, err := forge.NewEventStream(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	stream.Send(forge.Event{Data: "x\rid: 999\revent: admin\r\nlast"})
+	stream.Close()
+
+	if body := recorder.Body.String(); body != "data: x\ndata: id: 999\ndata: event: admin\ndata: last\n\n" {
+		t.Fatalf("unexpected body: %q", body)
+	}
+}
+
+func Test_EventStream_Heartbeat(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream, err := forge.NewEventStream(w, r)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer stream.Close()
+
+		stream.StartHeartbeat(time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+	})
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:          handler,
+		Request:          request,
+		TargetStatusCode: http.StatusOK,
+		CustomResponseChecker: func(t *testing.T, response *http.Response) {
+			responseBytes, _ := ioutil.ReadAll(response.Body)
+			if !strings.HasPrefix(string(responseBytes), ": heartbeat\n\n") {
+				t.Fatalf("expected heartbeat, got: %q", responseBytes)
+			}
+		},
+	})
+}
+
+func Test_EventStream_Unsupported(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if _, err := forge.NewEventStream(nonFlushingWriter{httptest.NewRecorder()}, request); err != forge.ErrStreamingUnsupported {
+		t.Fatalf("expected ErrStreamingUnsupported, got: %v", err)
+	}
+
+	if _, err := forge.NewNDJSONStream(nonFlushingWriter{httptest.NewRecorder()}, request); err != forge.ErrStreamingUnsupported {
+		t.Fatalf("expected ErrStreamingUnsupported, got: %v", err)
+	}
+
+	logger := &forge.Logger{
+		Log: log.New(ioutil.Discard, "", 0),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := forge.NewEventStream(w, r); err != forge.ErrStreamingUnsupported {
+				t.Fatalf("Logger should not add http.Flusher, got: %v", err)
+			}
+		}),
+	}
+
+	logger.ServeHTTP(nonFlushingWriter{httptest.NewRecorder()}, request)
+}
+
+func Test_EventStream_HeartbeatInvalidInterval(t *testing.T) {
+	recorder := httptest.NewRecorder()
+
+	stream, err := forge.NewEventStream(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	stream.StartHeartbeat(0)
+	stream.StartHeartbeat(-time.Second)
+	time.Sleep(10 * time.Millisecond)
+	stream.Close()
+
+	if strings.Contains(recorder.Body.String(), "heartbeat") {
+		t.Fatalf("unexpected heartbeat: %q", recorder.Body.String())
+	}
+}
+
+func Test_NDJSONStream_Success(t *testing.T) {
+	logger := &forge.Logger{
+		Log: log.New(ioutil.Discard, "", 0),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			stream, err := forge.NewNDJSONStream(w, r)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			for i := 1; i <= 3; i++ {
+				stream.Encode(map[string]int{"item": i})
+			}
+		}),
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	handlerTest(t, handlerTestCase{
+		Handler:               logger,
+		Request:               request,
+		TargetStatusCode:      http.StatusOK,
+		CustomResponseChecker: statusAndBodyChecker(http.StatusOK, "{\"item\":1}\n{\"item\":2}\n{\"item\":3}\n"),
+	})
+}
@@ -0,0 +1,213 @@
+package forge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTokenNotFound is returned when a TokenStore does not know a token
+	ErrTokenNotFound = errors.New("token not found")
+
+	// ErrTokenExpired is returned when a token is past its expiry
+	ErrTokenExpired = errors.New("token expired")
+
+	// ErrTokenScope is returned when a token lacks a required scope
+	ErrTokenScope = errors.New("token missing required scope")
+)
+
+// Token is an API key or opaque bearer token known to a TokenStore
+type Token struct {
+	User      User
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// Expired checks if the Token is past its expiry, a zero ExpiresAt never expires
+func (token *Token) Expired(now time.Time) bool {
+	return !token.ExpiresAt.IsZero() && !now.Before(token.ExpiresAt)
+}
+
+// HasScope checks if the Token was granted a scope
+func (token *Token) HasScope(scope string) bool {
+	for _, tokenScope := range token.Scopes {
+		if tokenScope == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TokenStore looks up Tokens by their key, which is the SHA-256 hash from
+// HashToken when the TokenGuard hashes tokens
+type TokenStore interface {
+	LookupToken(key string) (*Token, error)
+}
+
+// HashToken hashes a raw token so it can be stored at rest
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// MemoryTokenStore is a TokenStore kept in memory, useful for static API keys and tests
+type MemoryTokenStore struct {
+	tokens map[string]*Token
+	mutex  sync.RWMutex
+}
+
+// Add stores a Token under a key
+func (store *MemoryTokenStore) Add(key string, token Token) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.tokens == nil {
+		store.tokens = make(map[string]*Token)
+	}
+
+	store.tokens[key] = &token
+}
+
+// Remove deletes the Token stored under a key
+func (store *MemoryTokenStore) Remove(key string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.tokens, key)
+}
+
+// LookupToken satisfies the TokenStore interface
+func (store *MemoryTokenStore) LookupToken(key string) (*Token, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	token, ok := store.tokens[key]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+
+	// Return a copy so callers can not modify the stored Token
+	tokenCopy := *token
+	tokenCopy.Scopes = append([]string(nil), token.Scopes...)
+
+	return &tokenCopy, nil
+}
+
+// TokenGuard is a Guard for API keys and opaque bearer tokens. Tokens are read from
+// the "Authorization: Bearer" header, then Header and then QueryParameter.
+type TokenGuard struct {
+	Store TokenStore
+	// Header is an additional header carrying the token, like "X-API-Key"
+	Header string
+	// QueryParameter is a query parameter carrying the token, like "api_key"
+	QueryParameter string
+	// HashTokens looks tokens up by their HashToken hash instead of the raw value
+	HashTokens bool
+	// RequiredScopes must all be granted to the Token
+	RequiredScopes []string
+	// Realm is sent in the WWW-Authenticate challenge
+	Realm string
+}
+
+// Supports satisfies the Guard interface
+func (guard *TokenGuard) Supports(request *http.Request) bool {
+	return guard.token(request) != ""
+}
+
+// GetCredentials satisfies the Guard interface
+func (guard *TokenGuard) GetCredentials(request *http.Request) interface{} {
+	return guard.token(request)
+}
+
+// GetUser satisfies the Guard interface
+func (guard *TokenGuard) GetUser(credentials interface{}) (User, error) {
+	rawToken, ok := credentials.(string)
+	if !ok || rawToken == "" || guard.Store == nil {
+		return nil, ErrTokenNotFound
+	}
+
+	key := rawToken
+	if guard.HashTokens {
+		key = HashToken(rawToken)
+	}
+
+	token, err := guard.Store.LookupToken(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil {
+		return nil, ErrTokenNotFound
+	}
+
+	if token.Expired(time.Now()) {
+		return nil, ErrTokenExpired
+	}
+
+	for _, scope := range guard.RequiredScopes {
+		if !token.HasScope(scope) {
+			return nil, ErrTokenScope
+		}
+	}
+
+	return token.User, nil
+}
+
+// CheckCredentials satisfies the Guard interface, the token was verified by GetUser
+func (guard *TokenGuard) CheckCredentials(user interface{}, credentials interface{}) (bool, error) {
+	return user != nil, nil
+}
+
+// OnAuthenticationFailure satisfies the Guard interface. Tokens lacking a required
+// scope are forbidden with an insufficient_scope challenge as in RFC 6750 section 3.1.
+func (guard *TokenGuard) OnAuthenticationFailure(w http.ResponseWriter, r *http.Request) {
+	if errors.Is(AuthenticationError(r), ErrTokenScope) {
+		w.Header().Set("WWW-Authenticate", guard.challenge(`error="insufficient_scope", scope="`+quoteChallenge(strings.Join(guard.RequiredScopes, " "))+`"`))
+		RespondError(w, r, NewError(http.StatusForbidden, ""))
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", guard.challenge(`error="invalid_token"`))
+	RespondError(w, r, NewError(http.StatusUnauthorized, ""))
+}
+
+// OnAuthenticationSuccess satisfies the Guard interface
+func (guard *TokenGuard) OnAuthenticationSuccess(w http.ResponseWriter, r *http.Request) {}
+
+func (guard *TokenGuard) challenge(parameters string) string {
+	if guard.Realm == "" {
+		return "Bearer " + parameters
+	}
+
+	return `Bearer realm="` + quoteChallenge(guard.Realm) + `", ` + parameters
+}
+
+// quoteChallenge escapes a value for a quoted-string in a WWW-Authenticate challenge
+func quoteChallenge(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
+
+func (guard *TokenGuard) token(request *http.Request) string {
+	if token := bearerToken(request); token != "" {
+		return token
+	}
+
+	if guard.Header != "" {
+		if token := strings.TrimSpace(request.Header.Get(guard.Header)); token != "" {
+			return token
+		}
+	}
+
+	if guard.QueryParameter != "" {
+		return request.URL.Query().Get(guard.QueryParameter)
+	}
+
+	return ""
+}
@@ -0,0 +1,107 @@
+package forge_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fuzzingbits/forge"
+)
+
+func Test_TokenGuard(t *testing.T) {
+	store := &forge.MemoryTokenStore{}
+	store.Add(forge.HashToken("valid-token"), forge.Token{
+		User:   &securityTestUser{Username: "service"},
+		Scopes: []string{"read", "write"},
+	})
+	store.Add(forge.HashToken("read-only-token"), forge.Token{
+		User:   &securityTestUser{Username: "reader"},
+		Scopes: []string{"read"},
+	})
+	store.Add(forge.HashToken("expired-token"), forge.Token{
+		User:      &securityTestUser{Username: "expired"},
+		Scopes:    []string{"read", "write"},
+		ExpiresAt: time.Now().Add(-time.Hour),
+	})
+
+	security := &forge.Security{
+		Guards: []forge.Guard{
+			&forge.TokenGuard{
+				Store:          store,
+				Header:         "X-API-Key",
+				QueryParameter: "api_key",
+				HashTokens:     true,
+				RequiredScopes: []string{"write"},
+			},
+		},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("Hello " + forge.CurrentUser(r).GetUsername()))
+		}),
+	}
+
+	unauthorized := `{"status":false,"message":"Unauthorized","data":{}}` + "\n"
+	forbidden := `{"status":false,"message":"Forbidden","data":{}}` + "\n"
+
+	testCases := []struct {
+		Name       string
+		Path       string
+		Header     string
+		Value      string
+		StatusCode int
+		Body       string
+		Challenge  string
+	}{
+		{Name: "bearer", Path: "/", Header: "Authorization", Value: "Bearer valid-token", StatusCode: http.StatusOK, Body: "Hello service"},
+		{Name: "header", Path: "/", Header: "X-API-Key", Value: "valid-token", StatusCode: http.StatusOK, Body: "Hello service"},
+		{Name: "query", Path: "/?api_key=valid-token", StatusCode: http.StatusOK, Body: "Hello service"},
+		{Name: "unknown", Path: "/", Header: "Authorization", Value: "Bearer unknown-token", StatusCode: http.StatusUnauthorized, Body: unauthorized, Challenge: `Bearer error="invalid_token"`},
+		{Name: "expired", Path: "/", Header: "Authorization", Value: "Bearer expired-token", StatusCode: http.StatusUnauthorized, Body: unauthorized},
+		{Name: "scope", Path: "/", Header: "Authorization", Value: "Bearer read-only-token", StatusCode: http.StatusForbidden, Body: forbidden, Challenge: `Bearer error="insufficient_scope", scope="write"`},
+		{Name: "missing", Path: "/", StatusCode: http.StatusUnauthorized, Body: http.StatusText(http.StatusUnauthorized)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, testCase.Path, nil)
+			if testCase.Header != "" {
+				request.Header.Set(testCase.Header, testCase.Value)
+			}
+
+			handlerTest(t, handlerTestCase{
+				Handler:          security,
+				Request:          request,
+				TargetStatusCode: testCase.StatusCode,
+				CustomResponseChecker: func(t *testing.T, response *http.Response) {
+					if testCase.Challenge != "" && response.Header.Get("WWW-Authenticate") != testCase.Challenge {
+						t.Fatalf("challenge: %s", response.Header.Get("WWW-Authenticate"))
+					}
+
+					statusAndBodyChecker(testCase.StatusCode, testCase.Body)(t, response)
+				},
+			})
+		})
+	}
+}
+
+func Test_MemoryTokenStore_Remove(t *testing.T) {
+	store := &forge.MemoryTokenStore{}
+	store.Add("key", forge.Token{})
+	store.Remove("key")
+
+	if _, err := store.LookupToken("key"); err != forge.ErrTokenNotFound {
+		t.Fatalf("expected ErrTokenNotFound, got: %v", err)
+	}
+}
+
+func Test_MemoryTokenStore_LookupCopy(t *testing.T) {
+	store := &forge.MemoryTokenStore{}
+	store.Add("key", forge.Token{Scopes: []string{"read"}})
+
+	token, _ := store.LookupToken("key")
+	token.Scopes[0] = "write"
+	token.ExpiresAt = time.Now()
+
+	if token, _ := store.LookupToken("key"); token.Scopes[0] != "read" || !token.ExpiresAt.IsZero() {
+		t.Fatalf("stored token was modified: %v", token)
+	}
+}